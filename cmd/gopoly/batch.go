@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// batchRequest is one input line: a ChatRequest with an optional id.
+// Lines without an id are named after their line number, which only
+// resumes correctly if the input file is not edited in between.
+type batchRequest struct {
+	ID string `json:"id"`
+	ai.ChatRequest
+}
+
+// batchResult is one output line.
+type batchResult struct {
+	ID           string          `json:"id"`
+	Model        string          `json:"model,omitempty"`
+	Content      string          `json:"content,omitempty"`
+	FinishReason ai.FinishReason `json:"finish_reason,omitempty"`
+	Usage        *ai.TokenUsage  `json:"usage,omitempty"`
+	CostUSD      float64         `json:"cost_usd"`
+	LatencyMS    int64           `json:"latency_ms"`
+	Error        string          `json:"error,omitempty"`
+}
+
+type batchStats struct {
+	done, failed, skipped int
+	unnamed               int // lines identified by their line number
+	usage                 ai.TokenUsage
+}
+
+// runBatch implements `gopoly batch --in prompts.jsonl --out results.jsonl`.
+// Results are appended, so an interrupted run resumes where it stopped and
+// retries the requests that failed; the last line for an id is current.
+func runBatch(g *globals, args []string) int {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	g.register(fs)
+	var cf clientFlags
+	cf.register(fs)
+	in := fs.String("in", "", "Input JSONL file of requests (- for stdin)")
+	out := fs.String("out", "", "Output JSONL file, appended to and used to resume")
+	concurrency := fs.Int("concurrency", 4, "Requests in flight at once")
+	fs.Parse(args)
+
+	if *in == "" || *out == "" || *concurrency < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: gopoly batch --in prompts.jsonl --out results.jsonl [-concurrency n] [flags]")
+		return 2
+	}
+
+	input := os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		input = f
+	}
+
+	done, err := completedIDs(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	output, err := os.OpenFile(*out, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	defer output.Close()
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	client, err := cf.build(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	defer closeProvider(client)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "--- 📦 %s · %d already done ---\n", client.Name(), len(done))
+	start := time.Now()
+	stats, err := batch(ctx, client, cf.modelFor(cfg), input, output, done, *concurrency)
+	fmt.Fprintf(os.Stderr, "%d done, %d failed, %d skipped · %d in / %d out tokens · $%.6f · %v\n",
+		stats.done, stats.failed, stats.skipped, stats.usage.InputTokens, stats.usage.OutputTokens,
+		stats.usage.CostUSD, time.Since(start).Round(time.Millisecond))
+	if stats.unnamed > 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  %d request(s) have no id and are resumed by line number; do not edit the input before resuming\n", stats.unnamed)
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		fmt.Fprintln(os.Stderr, "⚠️  interrupted; run again to resume")
+		return 130
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	case stats.failed > 0:
+		return 1
+	}
+	return 0
+}
+
+// completedIDs reads the ids an earlier run already answered successfully;
+// failed requests are run again. A line cut short by a crash is ignored,
+// and a newline is added after it so the next result starts on a line of
+// its own.
+func completedIDs(path string) (map[string]bool, error) {
+	done := make(map[string]bool)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var r batchResult
+		if json.Unmarshal(scanner.Bytes(), &r) == nil && r.ID != "" && r.Error == "" {
+			done[r.ID] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if _, err := f.WriteString("\n"); err != nil {
+			return nil, err
+		}
+	}
+	return done, nil
+}
+
+// batch runs every request in in whose id is not in done, with at most
+// concurrency in flight, and writes one result per line to out in
+// completion order. Requests cut short by ctx are not written, so a later
+// run retries them.
+func batch(ctx context.Context, client ai.AIProvider, model string, in io.Reader, out io.Writer, done map[string]bool, concurrency int) (batchStats, error) {
+	var (
+		stats    batchStats
+		mu       sync.Mutex
+		writeErr error
+		wg       sync.WaitGroup
+	)
+	enc := json.NewEncoder(out)
+	write := func(r batchResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Error != "" {
+			stats.failed++
+		} else {
+			stats.done++
+		}
+		if r.Usage != nil {
+			stats.usage.InputTokens += r.Usage.InputTokens
+			stats.usage.OutputTokens += r.Usage.OutputTokens
+			stats.usage.TotalTokens += r.Usage.TotalTokens
+			stats.usage.CostUSD += r.Usage.CostUSD
+		}
+		if err := enc.Encode(r); err != nil && writeErr == nil {
+			writeErr = err
+		}
+	}
+
+	sem := make(chan struct{}, concurrency)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for line := 1; scanner.Scan() && ctx.Err() == nil; line++ {
+		raw := scanner.Bytes()
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+
+		var req batchRequest
+		err := json.Unmarshal(raw, &req)
+		if req.ID == "" {
+			req.ID = fmt.Sprintf("line-%d", line)
+			stats.unnamed++
+		}
+		if done[req.ID] {
+			stats.skipped++
+			continue
+		}
+		done[req.ID] = true
+		if err != nil {
+			write(batchResult{ID: req.ID, Error: "invalid request: " + err.Error()})
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			r, ok := runOne(ctx, client, model, req)
+			if ok {
+				write(r)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := scanner.Err(); err != nil {
+		return stats, err
+	}
+	return stats, writeErr
+}
+
+func runOne(ctx context.Context, client ai.AIProvider, model string, req batchRequest) (batchResult, bool) {
+	if req.Model == "" {
+		req.Model = model
+	}
+	req.Stream = false
+
+	start := time.Now()
+	resp, err := client.Generate(ctx, req.ChatRequest)
+	r := batchResult{ID: req.ID, Model: req.Model, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		if ctx.Err() != nil {
+			return r, false
+		}
+		r.Error = err.Error()
+		return r, true
+	}
+
+	if resp.Model != "" {
+		r.Model = resp.Model
+	}
+	r.Content = resp.Content
+	r.FinishReason = resp.FinishReason
+	r.Usage = &resp.Usage
+	r.CostUSD = resp.Usage.CostUSD
+	return r, true
+}
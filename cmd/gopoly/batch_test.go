@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// upperProvider answers with the last message upper-cased and fails on "fail".
+type upperProvider struct {
+	echoProvider
+	calls atomic.Int32
+}
+
+func (p *upperProvider) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	p.calls.Add(1)
+	text := req.Messages[len(req.Messages)-1].Content[0].Text
+	if text == "fail" {
+		return nil, errors.New("boom")
+	}
+	return &ai.ChatResponse{
+		Content: strings.ToUpper(text),
+		Model:   req.Model,
+		Usage:   ai.TokenUsage{InputTokens: 1, OutputTokens: 1, TotalTokens: 2, CostUSD: 0.25},
+	}, nil
+}
+
+func TestBatchResumes(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "results.jsonl")
+	line := func(id, text string) string {
+		return `{"id":"` + id + `","messages":[{"role":"user","content":[{"type":"text","text":"` + text + `"}]}]}`
+	}
+
+	// A previous run finished "a" and crashed while writing "b".
+	os.WriteFile(out, []byte(`{"id":"a","content":"A"}`+"\n"+`{"id":"b","con`), 0o644)
+
+	input := strings.Join([]string{
+		line("a", "a"),
+		line("b", "b"),
+		"",
+		line("c", "fail"),
+		`{"messages":[{"role":"user","content":[{"type":"text","text":"no id"}]}]}`,
+		"not json",
+		line("b", "duplicate"),
+	}, "\n")
+
+	done, err := completedIDs(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &upperProvider{}
+	stats, err := batch(context.Background(), p, "gpt-4o", strings.NewReader(input), f, done, 2)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.done != 2 || stats.failed != 2 || stats.skipped != 2 || p.calls.Load() != 3 {
+		t.Errorf("stats = %+v, calls = %d", stats, p.calls.Load())
+	}
+	if stats.usage.CostUSD != 0.5 {
+		t.Errorf("cost = %v", stats.usage.CostUSD)
+	}
+
+	data, _ := os.ReadFile(out)
+	results := make(map[string]batchResult)
+	for _, l := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var r batchResult
+		if json.Unmarshal([]byte(l), &r) == nil {
+			results[r.ID] = r
+		}
+	}
+	if r := results["b"]; r.Content != "B" || r.Model != "gpt-4o" || r.Usage == nil {
+		t.Errorf("b = %+v", r)
+	}
+	if results["c"].Error != "boom" {
+		t.Errorf("c = %+v", results["c"])
+	}
+	if results["line-5"].Content != "NO ID" {
+		t.Errorf("line-5 = %+v", results["line-5"])
+	}
+	if !strings.HasPrefix(results["line-6"].Error, "invalid request") {
+		t.Errorf("line-6 = %+v", results["line-6"])
+	}
+
+	if stats.unnamed != 2 {
+		t.Errorf("unnamed = %d", stats.unnamed)
+	}
+
+	// A second run only retries the failures.
+	done, _ = completedIDs(out)
+	p.calls.Store(0)
+	stats, _ = batch(context.Background(), p, "", strings.NewReader(input), &strings.Builder{}, done, 2)
+	if p.calls.Load() != 1 || stats.skipped != 4 || stats.failed != 2 {
+		t.Errorf("second run: stats = %+v, calls = %d", stats, p.calls.Load())
+	}
+}
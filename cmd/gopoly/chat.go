@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/conversation"
+)
+
+const chatHelp = `Commands:
+  /model [name]        show or change the model (aliases work too)
+  /provider <name>     switch provider, keeping the history
+  /system <prompt>     set the system prompt
+  /temperature [t]     show or set the temperature ("default" clears it)
+  /save                save the conversation to the sessions directory
+  /load <id>           load a saved conversation
+  /cost                token usage and cost of the conversation so far
+  /reset               forget the history, keeping the system prompt
+  /exit                quit`
+
+// chat is an interactive, streaming multi-turn session.
+type chat struct {
+	conv     *conversation.Conversation
+	memory   *conversation.MemoryStore
+	sessions string // directory used by /save and /load
+
+	// build returns the provider and the model it is configured with.
+	build func(provider string) (ai.AIProvider, string, error)
+
+	out io.Writer
+}
+
+// runChat implements `gopoly chat`.
+func runChat(g *globals, args []string) int {
+	fs := flag.NewFlagSet("chat", flag.ExitOnError)
+	g.register(fs)
+	var cf clientFlags
+	cf.register(fs)
+	system := fs.String("system", "", "System prompt")
+	sessions := fs.String("sessions", "sessions", "Directory for /save and /load")
+	fs.Parse(args)
+
+	// Every switch rebuilds from a fresh config; -k and -m only apply to
+	// the provider they were given for.
+	build := func(name string) (ai.AIProvider, string, error) {
+		if name != cf.provider {
+			cf.provider, cf.apiKey, cf.model = name, "", ""
+		}
+		cfg, err := g.load()
+		if err != nil {
+			return nil, "", err
+		}
+		p, err := cf.build(cfg)
+		if err != nil {
+			return nil, "", err
+		}
+		return p, cf.modelFor(cfg), nil
+	}
+
+	c, err := newChat(cf.provider, build, *sessions, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	defer func() { closeProvider(c.conv.Provider()) }()
+
+	if *system != "" {
+		if err := c.conv.SetSystem(context.Background(), *system); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+	}
+
+	fmt.Printf("--- 💬 %s --- (/help for commands, Ctrl-D to quit)\n", c.conv.Provider().Name())
+	c.run(context.Background(), os.Stdin)
+	return 0
+}
+
+func newChat(provider string, build func(string) (ai.AIProvider, string, error), sessions string, out io.Writer) (*chat, error) {
+	p, model, err := build(provider)
+	if err != nil {
+		return nil, err
+	}
+	memory := conversation.NewMemoryStore()
+	conv := conversation.New(p, memory)
+	conv.SetModel(model)
+	return &chat{
+		conv:     conv,
+		memory:   memory,
+		sessions: sessions,
+		build:    build,
+		out:      out,
+	}, nil
+}
+
+func (c *chat) run(ctx context.Context, in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for {
+		fmt.Fprint(c.out, "you> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(c.out)
+			return
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "/"):
+			if !c.command(ctx, line) {
+				return
+			}
+		default:
+			c.send(ctx, line)
+		}
+	}
+}
+
+// send streams one reply. Ctrl-C cancels the reply, not the session.
+func (c *chat) send(ctx context.Context, text string) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	stream, err := c.conv.SendStream(ctx, text)
+	if err != nil {
+		fmt.Fprintf(c.out, "⚠️  %v\n", err)
+		return
+	}
+
+	fmt.Fprint(c.out, "ai> ")
+	var streamErr error
+	for packet := range stream {
+		if packet.Err != nil {
+			streamErr = packet.Err
+			continue
+		}
+		fmt.Fprint(c.out, packet.Chunk)
+	}
+	fmt.Fprintln(c.out)
+
+	if streamErr == nil && ctx.Err() != nil {
+		streamErr = ctx.Err()
+	}
+	if streamErr != nil {
+		fmt.Fprintf(c.out, "⚠️  %v (turn discarded)\n", streamErr)
+		return
+	}
+
+	turns := c.conv.Turns()
+	last := turns[len(turns)-1]
+	fmt.Fprintf(c.out, "    [%s]\n", usageLine(last))
+}
+
+func usageLine(t conversation.Turn) string {
+	model := t.Model
+	if t.Alias != "" {
+		model = t.Alias + " → " + t.Model
+	}
+	if model != "" {
+		model += " · "
+	}
+	if t.Usage == nil {
+		return model + "usage not reported"
+	}
+	return fmt.Sprintf("%s%d in / %d out tokens · $%.6f", model, t.Usage.InputTokens, t.Usage.OutputTokens, t.Usage.CostUSD)
+}
+
+// command runs a slash command and reports whether the session continues.
+func (c *chat) command(ctx context.Context, line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "/exit", "/quit":
+		return false
+
+	case "/help":
+		fmt.Fprintln(c.out, chatHelp)
+
+	case "/model":
+		if arg != "" {
+			c.conv.SetModel(arg)
+		}
+		model := c.conv.Template().Model
+		if model == "" {
+			model = "(provider default)"
+		}
+		fmt.Fprintf(c.out, "model: %s\n", model)
+
+	case "/provider":
+		if arg == "" {
+			fmt.Fprintf(c.out, "provider: %s\n", c.conv.Provider().Name())
+			break
+		}
+		p, model, err := c.build(arg)
+		if err != nil {
+			fmt.Fprintf(c.out, "⚠️  %v\n", err)
+			break
+		}
+		old := c.conv.Provider()
+		c.conv.SetProvider(p)
+		closeProvider(old)
+		c.conv.SetModel(model)
+		fmt.Fprintf(c.out, "provider: %s\n", p.Name())
+
+	case "/system":
+		if arg == "" {
+			fmt.Fprintln(c.out, "usage: /system <prompt>")
+			break
+		}
+		if err := c.conv.SetSystem(ctx, arg); err != nil {
+			fmt.Fprintf(c.out, "⚠️  %v\n", err)
+			break
+		}
+		fmt.Fprintln(c.out, "system prompt set")
+
+	case "/temperature":
+		switch arg {
+		case "":
+		case "default":
+			c.conv.SetTemperature(nil)
+		default:
+			t, err := strconv.ParseFloat(arg, 64)
+			if err != nil || t < 0 || t > 2 {
+				fmt.Fprintln(c.out, "⚠️  temperature must be a number between 0 and 2")
+				return true
+			}
+			c.conv.SetTemperature(ai.Float64(t))
+		}
+		if t := c.conv.Template().Temperature; t != nil {
+			fmt.Fprintf(c.out, "temperature: %g\n", *t)
+		} else {
+			fmt.Fprintln(c.out, "temperature: (provider default)")
+		}
+
+	case "/save":
+		if err := c.save(ctx); err != nil {
+			fmt.Fprintf(c.out, "⚠️  %v\n", err)
+			break
+		}
+		fmt.Fprintf(c.out, "saved as %s (/load %s)\n", c.conv.ID(), c.conv.ID())
+
+	case "/load":
+		if arg == "" {
+			fmt.Fprintln(c.out, "usage: /load <id>")
+			break
+		}
+		if err := c.load(ctx, arg); err != nil {
+			fmt.Fprintf(c.out, "⚠️  %v\n", err)
+			break
+		}
+		fmt.Fprintf(c.out, "loaded %s (%d turns)\n", arg, len(c.conv.Turns()))
+
+	case "/cost":
+		u := c.conv.Usage()
+		fmt.Fprintf(c.out, "total: %d in / %d out tokens · $%.6f\n", u.InputTokens, u.OutputTokens, u.CostUSD)
+
+	case "/reset":
+		if err := c.conv.Reset(ctx); err != nil {
+			fmt.Fprintf(c.out, "⚠️  %v\n", err)
+			break
+		}
+		fmt.Fprintln(c.out, "history cleared")
+
+	default:
+		fmt.Fprintf(c.out, "unknown command %s, try /help\n", name)
+	}
+	return true
+}
+
+// save copies the session from memory into the sessions directory.
+func (c *chat) save(ctx context.Context) error {
+	store, err := conversation.NewFileStore(c.sessions)
+	if err != nil {
+		return err
+	}
+	if err := c.conv.Save(ctx); err != nil {
+		return err
+	}
+	s, err := c.memory.Load(ctx, c.conv.ID())
+	if err != nil {
+		return err
+	}
+	return store.Save(ctx, s)
+}
+
+// load replaces the conversation with a saved one, keeping the current
+// provider. The model, temperature and system prompt come from the session.
+func (c *chat) load(ctx context.Context, id string) error {
+	store, err := conversation.NewFileStore(c.sessions)
+	if err != nil {
+		return err
+	}
+	s, err := store.Load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := c.memory.Save(ctx, s); err != nil {
+		return err
+	}
+
+	conv, err := conversation.Load(ctx, c.conv.Provider(), c.memory, id)
+	if err != nil {
+		return err
+	}
+	c.conv = conv
+	return nil
+}
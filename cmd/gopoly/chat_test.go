@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+type echoProvider struct {
+	name string
+	last ai.ChatRequest
+}
+
+func (p *echoProvider) Configure(cfg ai.Config) error { return nil }
+func (p *echoProvider) Name() string                  { return p.name }
+func (p *echoProvider) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	return nil, ai.ErrProviderDown
+}
+func (p *echoProvider) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	p.last = req
+	ch := make(chan ai.StreamResponse, 3)
+	ch <- ai.StreamResponse{Chunk: p.name + " says "}
+	ch <- ai.StreamResponse{Chunk: "hi"}
+	ch <- ai.StreamResponse{Usage: &ai.TokenUsage{InputTokens: 10, OutputTokens: 2, CostUSD: 0.5}}
+	close(ch)
+	return ch, nil
+}
+
+func TestChatSession(t *testing.T) {
+	providers := map[string]*echoProvider{"openai": {name: "openai"}, "ollama": {name: "ollama"}}
+	models := map[string]string{"openai": "gpt-3.5-turbo", "ollama": "llama3"}
+	build := func(name string) (ai.AIProvider, string, error) { return providers[name], models[name], nil }
+
+	var out strings.Builder
+	c, err := newChat("openai", build, t.TempDir(), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := strings.Join([]string{
+		"/system be brief",
+		"/model gpt-4o",
+		"/temperature 0.3",
+		"hello",
+		"/save",
+		"/provider ollama",
+		"again",
+		"/cost",
+		"/reset",
+		"/bogus",
+	}, "\n")
+	c.run(context.Background(), strings.NewReader(script))
+
+	got := out.String()
+	for _, want := range []string{
+		"ai> openai says hi",
+		"[gpt-4o · 10 in / 2 out tokens · $0.500000]",
+		"ai> ollama says hi",
+		"total: 20 in / 4 out tokens · $1.000000",
+		"history cleared",
+		"unknown command /bogus",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output misses %q:\n%s", want, got)
+		}
+	}
+
+	req := providers["ollama"].last
+	if len(req.Messages) != 4 || req.Messages[0].Role != "system" {
+		t.Errorf("history not kept across providers: %+v", req.Messages)
+	}
+	if req.Temperature == nil || *req.Temperature != 0.3 || req.Model != "llama3" {
+		t.Errorf("switching provider should keep temperature and use its configured model: %+v", req)
+	}
+
+	// The saved session predates the second turn and the reset.
+	id := c.conv.ID()
+	out.Reset()
+	c.command(context.Background(), "/load "+id)
+	if !strings.Contains(out.String(), "(3 turns)") {
+		t.Errorf("load: %s", out.String())
+	}
+	if tmpl := c.conv.Template(); tmpl.Model != "gpt-4o" || tmpl.Temperature == nil || *tmpl.Temperature != 0.3 {
+		t.Errorf("load should restore the saved settings: %+v", tmpl)
+	}
+}
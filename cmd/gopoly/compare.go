@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// contender is one provider taking part in a comparison.
+type contender struct {
+	name     string
+	model    string
+	provider ai.AIProvider
+}
+
+// comparison is the outcome for one contender.
+type comparison struct {
+	Provider  string         `json:"provider"`
+	Model     string         `json:"model,omitempty"`
+	Content   string         `json:"content"`
+	Usage     *ai.TokenUsage `json:"usage,omitempty"`
+	LatencyMS int64          `json:"latency_ms"`
+	TTFTMS    int64          `json:"ttft_ms,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// runCompare implements `gopoly compare -p openai,anthropic "prompt"`. Each
+// provider may name its own model as provider:model; -m is the default.
+func runCompare(g *globals, args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	g.register(fs)
+	var cf clientFlags
+	cf.register(fs)
+	system := fs.String("system", "", "System prompt")
+	temperature := fs.Float64("temperature", -1, "Sampling temperature (default from config)")
+	maxTokens := fs.Int("max-tokens", 0, "Maximum output tokens (default from config)")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Timeout per provider")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	fs.Parse(args)
+
+	prompt := strings.Join(fs.Args(), " ")
+	if prompt == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		prompt = string(data)
+	}
+	if cf.provider == "" || strings.TrimSpace(prompt) == "" {
+		fmt.Fprintln(os.Stderr, `Usage: gopoly compare -p openai,anthropic[:model],... [flags] "prompt" (or - to read stdin)`)
+		return 2
+	}
+	specs := strings.Split(cf.provider, ",")
+	if cf.apiKey != "" && len(specs) > 1 {
+		fmt.Fprintln(os.Stderr, "ERROR: -k needs a single provider; set the keys in the config")
+		return 2
+	}
+
+	var contenders []contender
+	defer func() {
+		for _, c := range contenders {
+			closeProvider(c.provider)
+		}
+	}()
+	for _, spec := range specs {
+		name, model, _ := strings.Cut(strings.TrimSpace(spec), ":")
+		if model == "" {
+			model = cf.model
+		}
+
+		cfg, err := g.load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		if model == "" {
+			model = cfg.Providers[name].Model
+		}
+		one := cf
+		one.provider = name
+		p, err := one.build(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %s: %v\n", name, err)
+			return 1
+		}
+		contenders = append(contenders, contender{name: name, model: model, provider: p})
+	}
+
+	req := ai.ChatRequest{MaxTokens: *maxTokens}
+	if *system != "" {
+		req.Messages = append(req.Messages, textMessage("system", *system))
+	}
+	req.Messages = append(req.Messages, textMessage("user", prompt))
+	if *temperature >= 0 {
+		req.Temperature = ai.Float64(*temperature)
+	}
+
+	results := compare(context.Background(), req, contenders, *timeout)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+	} else {
+		printComparison(os.Stdout, results)
+	}
+
+	for _, r := range results {
+		if r.Error == "" {
+			return 0
+		}
+	}
+	return 1
+}
+
+// compare streams req to every contender at once and returns the results
+// in the order given.
+func compare(ctx context.Context, req ai.ChatRequest, contenders []contender, timeout time.Duration) []comparison {
+	results := make([]comparison, len(contenders))
+	var wg sync.WaitGroup
+	for i, c := range contenders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			results[i] = contend(ctx, req, c)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func contend(ctx context.Context, req ai.ChatRequest, c contender) comparison {
+	r := comparison{Provider: c.name, Model: c.model}
+	req.Model = c.model
+	req.Stream = true
+
+	start := time.Now()
+	stream, err := c.provider.GenerateStream(ctx, req)
+	if err != nil {
+		r.Error = err.Error()
+		r.LatencyMS = time.Since(start).Milliseconds()
+		return r
+	}
+
+	var content strings.Builder
+	for packet := range stream {
+		if packet.Err != nil {
+			r.Error = packet.Err.Error()
+			continue
+		}
+		if packet.Usage != nil {
+			r.Usage = packet.Usage
+		}
+		if packet.Chunk != "" && r.TTFTMS == 0 {
+			r.TTFTMS = max(time.Since(start).Milliseconds(), 1)
+		}
+		content.WriteString(packet.Chunk)
+	}
+	r.Content = content.String()
+	r.LatencyMS = time.Since(start).Milliseconds()
+	return r
+}
+
+func printComparison(out io.Writer, results []comparison) {
+	for _, r := range results {
+		fmt.Fprintf(out, "=== %s", r.Provider)
+		if r.Model != "" {
+			fmt.Fprintf(out, " (%s)", r.Model)
+		}
+		fmt.Fprintln(out, " ===")
+		if r.Error != "" {
+			fmt.Fprintf(out, "⚠️  %s\n", r.Error)
+		}
+		if r.Content != "" {
+			fmt.Fprintln(out, strings.TrimSpace(r.Content))
+		}
+		fmt.Fprintln(out)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "PROVIDER\tMODEL\tLATENCY\tTTFT\tIN\tOUT\tCOST")
+	for _, r := range results {
+		ttft, in, outTokens, cost := "-", "-", "-", "-"
+		if r.TTFTMS > 0 {
+			ttft = (time.Duration(r.TTFTMS) * time.Millisecond).String()
+		}
+		if r.Usage != nil {
+			in, outTokens = fmt.Sprint(r.Usage.InputTokens), fmt.Sprint(r.Usage.OutputTokens)
+			cost = fmt.Sprintf("$%.6f", r.Usage.CostUSD)
+		}
+		latency := (time.Duration(r.LatencyMS) * time.Millisecond).String()
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Provider, r.Model, latency, ttft, in, outTokens, cost)
+	}
+}
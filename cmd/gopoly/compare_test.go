@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// failingProvider fails every stream before it starts.
+type failingProvider struct{ echoProvider }
+
+func (p *failingProvider) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	return nil, ai.ErrProviderDown
+}
+
+func TestCompare(t *testing.T) {
+	openai := &echoProvider{name: "openai"}
+	contenders := []contender{
+		{name: "openai", model: "gpt-4o", provider: openai},
+		{name: "ollama", provider: &failingProvider{}},
+	}
+	req := ai.ChatRequest{Messages: []ai.ChatMessage{textMessage("user", "hi")}}
+
+	results := compare(context.Background(), req, contenders, time.Second)
+
+	if r := results[0]; r.Provider != "openai" || r.Content != "openai says hi" || r.Usage == nil || r.TTFTMS == 0 || r.Error != "" {
+		t.Errorf("openai = %+v", r)
+	}
+	if openai.last.Model != "gpt-4o" || !openai.last.Stream {
+		t.Errorf("request = %+v", openai.last)
+	}
+	if r := results[1]; r.Provider != "ollama" || r.Error == "" {
+		t.Errorf("ollama = %+v", r)
+	}
+
+	var out strings.Builder
+	printComparison(&out, results)
+	for _, want := range []string{"=== openai (gpt-4o) ===", "openai says hi", "⚠️  ", "PROVIDER", "$0.500000"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
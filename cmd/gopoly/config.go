@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig implements `gopoly config validate|show`.
+func runConfig(g *globals, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: gopoly config validate|show [flags]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config "+args[0], flag.ExitOnError)
+	g.register(fs)
+	fs.Parse(args[1:])
+
+	switch args[0] {
+	case "validate":
+		return validateConfig(g)
+	case "show":
+		return showConfig(g)
+	}
+	fmt.Fprintf(os.Stderr, "unknown config command %q\n", args[0])
+	return 2
+}
+
+// validateConfig prints every problem with its position and exits
+// non-zero if any is an error.
+func validateConfig(g *globals) int {
+	path := g.config
+	if path == "" {
+		path = "config.yaml"
+	}
+
+	_, problems, err := config.Check(path, g.profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	errs := 0
+	for _, p := range problems {
+		fmt.Println(p)
+		if !p.Warning {
+			errs++
+		}
+	}
+	if errs > 0 {
+		fmt.Printf("❌ %s: %d error(s)\n", path, errs)
+		return 1
+	}
+	fmt.Printf("✅ %s is valid\n", path)
+	return 0
+}
+
+// showConfig prints the configuration after layering, interpolation and
+// environment overrides, with secrets redacted.
+func showConfig(g *globals) int {
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	os.Stdout.Write(out)
+	return 0
+}
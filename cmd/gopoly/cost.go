@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/middleware"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/tokenizer"
+	"github.com/ahmettasdemir/gopolyai/pkg/config"
+)
+
+// runCost implements `gopoly cost`. Without a model it prints the prices of
+// every configured model and alias; with -m it estimates the cost of a
+// prompt (or of -in tokens) plus -out output tokens.
+func runCost(g *globals, args []string) int {
+	fs := flag.NewFlagSet("cost", flag.ExitOnError)
+	g.register(fs)
+	provider := fs.String("p", "", "Provider whose cost stage prices the model (default active_provider)")
+	model := fs.String("m", "", "Model name or alias to estimate")
+	inTokens := fs.Int("in", 0, "Input tokens, instead of counting a prompt")
+	outTokens := fs.Int("out", 0, "Expected output tokens")
+	fs.Parse(args)
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	if *model == "" && fs.NArg() == 0 && *inTokens == 0 {
+		printPrices(cfg)
+		return 0
+	}
+
+	name, resolved := *provider, *model
+	if name == "" {
+		name = cfg.ActiveProvider
+	}
+	if a, ok := cfg.ModelAliases()[*model]; ok {
+		if a.Provider != "" && *provider == "" {
+			name = a.Provider
+		}
+		if a.Model != "" {
+			resolved = a.Model
+		}
+	}
+	if resolved == "" {
+		resolved = cfg.Providers[name].Model
+	}
+	if resolved == "" {
+		fmt.Fprintln(os.Stderr, "ERROR: no model given and the provider has no default model")
+		return 2
+	}
+
+	input := *inTokens
+	if prompt := strings.Join(fs.Args(), " "); prompt != "" {
+		req := ai.ChatRequest{Model: resolved, Messages: []ai.ChatMessage{textMessage("user", prompt)}}
+		if input, err = tokenizer.ForModel(resolved).CountTokens(req); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+	}
+
+	price, ok := modelPrice(cfg, name, resolved)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "ERROR: no price for model %q\n", resolved)
+		return 1
+	}
+	cost := (float64(input)*price.InputPrice + float64(*outTokens)*price.OutputPrice) / 1_000_000
+	fmt.Printf("%s: %d in + %d out tokens ≈ $%.6f\n", resolved, input, *outTokens, cost)
+	return 0
+}
+
+// modelPrice looks model up in the provider's cost stage, falling back to
+// the default catalog when the provider has none.
+func modelPrice(cfg *config.Config, provider, model string) (middleware.ModelPrice, bool) {
+	pricing, ok := cfg.Pricing(provider)
+	if !ok {
+		pricing = middleware.DefaultPricing
+	}
+	return middleware.LookupPrice(pricing, model)
+}
+
+func printPrices(cfg *config.Config) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "NAME\tPROVIDER\tMODEL\tINPUT $/1M\tOUTPUT $/1M")
+
+	row := func(label, provider, model string) {
+		price, ok := modelPrice(cfg, provider, model)
+		if !ok {
+			fmt.Fprintf(w, "%s\t%s\t%s\t-\t-\n", label, provider, model)
+			return
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\t%.2f\n", label, provider, model, price.InputPrice, price.OutputPrice)
+	}
+
+	providers := make([]string, 0, len(cfg.Providers))
+	for name := range cfg.Providers {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+	for _, name := range providers {
+		if m := cfg.Providers[name].Model; m != "" {
+			row(name, name, m)
+		}
+	}
+
+	aliases := cfg.ModelAliases()
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a := aliases[name]
+		provider := a.Provider
+		if provider == "" {
+			provider = cfg.ActiveProvider
+		}
+		model := a.Model
+		if model == "" {
+			model = cfg.Providers[provider].Model
+		}
+		row(name, provider, model)
+	}
+}
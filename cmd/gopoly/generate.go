@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// runGenerate implements `gopoly generate "prompt"`. The reply goes to
+// stdout; status, usage and telemetry go to stderr.
+func runGenerate(g *globals, args []string) int {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	g.register(fs)
+	var cf clientFlags
+	cf.register(fs)
+	streamMode := fs.Bool("s", false, "Turn on streaming mode")
+	structMode := fs.Bool("struct", false, "Turn on structured output mode")
+	system := fs.String("system", "", "System prompt")
+	temperature := fs.Float64("temperature", -1, "Sampling temperature (default from config)")
+	maxTokens := fs.Int("max-tokens", 0, "Maximum output tokens (default from config)")
+	fs.Parse(args)
+
+	prompt := strings.Join(fs.Args(), " ")
+	if prompt == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		prompt = string(data)
+	}
+	if strings.TrimSpace(prompt) == "" {
+		fmt.Fprintln(os.Stderr, `Usage: gopoly generate [flags] "prompt" (or - to read stdin)`)
+		return 2
+	}
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	client, err := cf.build(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	defer closeProvider(client)
+
+	fmt.Fprintf(os.Stderr, "--- 🧠 %s ---\n", client.Name())
+
+	req := ai.ChatRequest{Model: cf.modelFor(cfg), MaxTokens: *maxTokens}
+	if *system != "" {
+		req.Messages = append(req.Messages, textMessage("system", *system))
+	}
+	req.Messages = append(req.Messages, textMessage("user", prompt))
+	if *temperature >= 0 {
+		req.Temperature = ai.Float64(*temperature)
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	var usage *ai.TokenUsage
+
+	switch {
+	case *streamMode:
+		stream, err := client.GenerateStream(ctx, req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		for packet := range stream {
+			if packet.Err != nil {
+				fmt.Fprintf(os.Stderr, "\nStream Error: %v\n", packet.Err)
+				return 1
+			}
+			if packet.Usage != nil {
+				usage = packet.Usage
+			}
+			fmt.Print(packet.Chunk)
+		}
+		fmt.Println()
+
+	case *structMode:
+		type ResponseStruct struct {
+			Answer   string   `json:"answer" description:"The direct answer to the question"`
+			Keywords []string `json:"keywords" description:"List of important keywords related to the answer"`
+			IsCode   bool     `json:"is_code" description:"Whether the answer contains code snippets"`
+		}
+
+		var target ResponseStruct
+		if err := ai.GenerateStruct(ctx, client, req, &target); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		fmt.Printf("%+v\n", target)
+
+	default:
+		resp, err := client.Generate(ctx, req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		usage = &resp.Usage
+		fmt.Println(resp.Content)
+	}
+
+	if usage != nil {
+		fmt.Fprintf(os.Stderr, "[%d in / %d out tokens · $%.6f · %v]\n",
+			usage.InputTokens, usage.OutputTokens, usage.CostUSD, time.Since(start).Round(time.Millisecond))
+	}
+
+	return 0
+}
+
+func textMessage(role, text string) ai.ChatMessage {
+	return ai.ChatMessage{Role: role, Content: []ai.Content{{Type: "text", Text: text}}}
+}
+
+// closeProvider stops background work such as health probers and waits
+// for pending log writes.
+func closeProvider(p ai.AIProvider) {
+	if c, ok := ai.As[io.Closer](p); ok {
+		c.Close()
+	}
+}
@@ -1,137 +1,276 @@
 package main
 
 import (
-	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io/fs"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/ahmettasdemir/gopolyai/pkg/ai"
-	"github.com/ahmettasdemir/gopolyai/pkg/ai/anthropic"
-	"github.com/ahmettasdemir/gopolyai/pkg/ai/google"
 	"github.com/ahmettasdemir/gopolyai/pkg/ai/logger"
-	"github.com/ahmettasdemir/gopolyai/pkg/ai/middleware"
-	"github.com/ahmettasdemir/gopolyai/pkg/ai/ollama"
-	"github.com/ahmettasdemir/gopolyai/pkg/ai/openai"
+	_ "github.com/ahmettasdemir/gopolyai/pkg/ai/providers"
+	"github.com/ahmettasdemir/gopolyai/pkg/config"
+	"gopkg.in/yaml.v3"
 )
 
-type SimpleJSONLogger struct{}
+type command struct {
+	summary string
+	run     func(g *globals, args []string) int
+}
 
-func (s *SimpleJSONLogger) Log(ctx context.Context, entry logger.LogEntry) {
-	data, _ := json.MarshalIndent(entry, "", "  ")
-	fmt.Printf("\n📝 [TELEMETRY LOG] >>\n%s\n", string(data))
+var commands = map[string]command{
+	"generate": {"send one prompt and print the reply", runGenerate},
+	"chat":     {"interactive multi-turn chat", runChat},
+	"models":   {"list the models a provider offers", runModels},
+	"config":   {"validate or show the effective configuration", runConfig},
+	"cost":     {"show prices or estimate the cost of a prompt", runCost},
+	"batch":    {"run a JSONL file of requests, resumably", runBatch},
+	"compare":  {"send one prompt to several providers side by side", runCompare},
+	"serve":    {"serve the pipeline over HTTP with hot reload", runServe},
 }
 
 func main() {
-	provider := flag.String("p", "ollama", "AI Provider")
-	apiKey := flag.String("k", os.Getenv("AI_API_KEY"), "API Key")
-	modelName := flag.String("m", "", "Model name")
-	streamMode := flag.Bool("s", false, "Turn on streaming mode")
-	structMode := flag.Bool("struct", false, "Turn on structured output mode")
-	rateLimit := flag.Int("rate-limit", 0, "Rate limit (requests per second). 0 = unlimited")
-	flag.Parse()
-
-	prompt := "What is an interface in Go?"
-	if len(flag.Args()) > 0 {
-		prompt = flag.Args()[0]
-	}
-
-	var baseClient ai.AIProvider
-	switch *provider {
-	case "openai":
-		baseClient = openai.NewClient(*apiKey)
-	case "google":
-		baseClient = google.NewClient(*apiKey)
-	case "anthropic":
-		baseClient = anthropic.NewClient(*apiKey)
-	case "ollama":
-		baseClient = ollama.NewClient()
-	default:
-		log.Fatalf("Unknown provider: %s", *provider)
-	}
+	os.Exit(run(os.Args[1:]))
+}
 
-	cfg := ai.Config{Temperature: 0.7}
-	if *modelName != "" {
-		cfg.ModelName = *modelName
+func run(args []string) int {
+	g := &globals{profile: os.Getenv(config.ProfileEnv)}
+	fs := flag.NewFlagSet("gopoly", flag.ContinueOnError)
+	g.register(fs)
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
 	}
-	baseClient.Configure(cfg)
 
-	pricedClient := middleware.NewCostEstimator(baseClient)
+	if fs.NArg() == 0 {
+		usage(fs)
+		return 2
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", fs.Arg(0))
+		usage(fs)
+		return 2
+	}
+	return cmd.run(g, fs.Args()[1:])
+}
 
-	var rateLimitedClient ai.AIProvider = pricedClient
-	if *rateLimit > 0 {
-		fmt.Printf(">> Rate Limiter Active: %d req/s\n", *rateLimit)
-		rateLimitedClient = middleware.NewRateLimiterMiddleware(pricedClient, *rateLimit, *rateLimit)
+func usage(fs *flag.FlagSet) {
+	fmt.Fprintln(os.Stderr, "Usage: gopoly [--config file] [--profile name] <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", name, commands[name].summary)
 	}
+	fmt.Fprintln(os.Stderr, "\nGlobal flags:")
+	fs.PrintDefaults()
+	fmt.Fprintln(os.Stderr, "\nRun 'gopoly <command> -h' for the flags of a command.")
+}
+
+// globals are the flags shared by every command. They are accepted before
+// or after the command name.
+type globals struct {
+	config  string
+	profile string
+}
 
-	retryClient := middleware.NewResilientClient(rateLimitedClient, middleware.RetryConfig{
-		MaxRetries: 2, BaseDelay: 1 * time.Second, MaxDelay: 3 * time.Second,
-	})
+func (g *globals) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.config, "config", g.config, "Configuration file (default config.yaml, if present)")
+	fs.StringVar(&g.profile, "profile", g.profile, "Profile overlay, e.g. dev for config.dev.yaml (default $GOPOLY_PROFILE)")
+}
 
-	myLogger := &SimpleJSONLogger{}
-	logConfig := logger.Config{
-		LogPayloads:   true,
-		LogErrorsOnly: false,
+// load reads the configuration. Without --config, a missing config.yaml
+// falls back to defaultConfig so the CLI works out of the box.
+func (g *globals) load() (*config.Config, error) {
+	path := g.config
+	if path == "" {
+		path = "config.yaml"
+	}
+	cfg, err := config.Load(path, g.profile)
+	if errors.Is(err, fs.ErrNotExist) && g.config == "" {
+		return defaultConfig()
 	}
-	loggedClient := middleware.NewLoggingMiddleware(retryClient, myLogger, logConfig)
-	breakerClient := middleware.NewCircuitBreaker(loggedClient, 3, 30*time.Second)
+	return cfg, err
+}
 
-	finalClient := middleware.NewTracingMiddleware(breakerClient)
+// defaultYAML mirrors the chain the CLI used before it read config.yaml.
+const defaultYAML = `
+active_provider: ollama
+pipeline:
+  - tracing
+  - circuit_breaker: {threshold: 3, reset_timeout: 30s}
+  - logging: {payloads: true}
+  - retry: {max_retries: 2, base_delay: 1s, max_delay: 3s}
+  - cost
+providers:
+  openai: {temperature: 0.7}
+  anthropic: {temperature: 0.7}
+  google: {temperature: 0.7}
+  ollama: {temperature: 0.7}
+`
+
+func defaultConfig() (*config.Config, error) {
+	var cfg config.Config
+	if err := yaml.Unmarshal([]byte(defaultYAML), &cfg); err != nil {
+		return nil, err
+	}
+	for name, p := range cfg.Providers {
+		p.APIKey = config.Secret(apiKeyFor(name))
+		cfg.Providers[name] = p
+	}
+	return &cfg, nil
+}
 
-	fmt.Printf("--- 🧠 %s Initializing ---\n", finalClient.Name())
+// apiKeyEnv names the environment variable each provider's key is read
+// from when there is no config file.
+var apiKeyEnv = map[string]string{
+	ai.ProviderOpenAI:    "OPENAI_API_KEY",
+	ai.ProviderAnthropic: "ANTHROPIC_API_KEY",
+	ai.ProviderGoogle:    "GEMINI_API_KEY",
+}
 
-	req := ai.ChatRequest{
-		Model: *modelName,
-		Messages: []ai.ChatMessage{
-			{Role: "user", Content: []ai.Content{{Type: "text", Text: prompt}}},
-		},
-		Temperature: 0.7,
+func apiKeyFor(provider string) string {
+	if key := os.Getenv(apiKeyEnv[provider]); key != "" {
+		return key
 	}
+	return os.Getenv("AI_API_KEY")
+}
 
-	start := time.Now()
+// clientFlags select and tune the provider a command talks to. Pipeline
+// flags override the matching stage parameters from the configuration.
+type clientFlags struct {
+	provider string
+	apiKey   string
+	model    string
 
-	if *streamMode {
-		fmt.Println(">> STREAM MODE ACTIVE...")
-		streamChan, err := finalClient.GenerateStream(context.Background(), req)
-		if err != nil {
-			log.Fatalf("ERROR: %v", err)
-		}
+	retries          int
+	breakerThreshold int
+	breakerTimeout   time.Duration
+	rateLimit        int
+	log              string
+
+	fs *flag.FlagSet // reports which flags were given
+}
 
-		for packet := range streamChan {
-			if packet.Err != nil {
-				fmt.Printf("\n Stream Error: %v\n", packet.Err)
-				break
-			}
-			fmt.Print(packet.Chunk)
+func (c *clientFlags) register(fs *flag.FlagSet) {
+	c.fs = fs
+	fs.StringVar(&c.provider, "p", "", "Provider from the config, or auto (default active_provider)")
+	fs.StringVar(&c.apiKey, "k", "", "API key for the provider, overriding the config")
+	fs.StringVar(&c.model, "m", "", "Model name or alias")
+	fs.IntVar(&c.retries, "retries", 0, "Max retries, 0 to disable (overrides the retry stage)")
+	fs.IntVar(&c.breakerThreshold, "breaker-threshold", 0, "Failures before the circuit opens, 0 to disable (overrides circuit_breaker)")
+	fs.DurationVar(&c.breakerTimeout, "breaker-timeout", 0, "Time the circuit stays open (overrides circuit_breaker)")
+	fs.IntVar(&c.rateLimit, "rate-limit", 0, "Requests per second, 0 for unlimited (overrides rate_limit)")
+	fs.StringVar(&c.log, "log", "", "Logging: on, errors, payloads or off (overrides logging)")
+}
+
+// build applies the flags to cfg and builds the provider pipeline.
+func (c *clientFlags) build(cfg *config.Config) (ai.AIProvider, error) {
+	if err := c.apply(cfg); err != nil {
+		return nil, err
+	}
+	return config.BuildProvider(cfg, "", c.logger())
+}
+
+// modelFor returns -m, or the model configured for the provider build
+// selected, so requests name the model they are priced by. It is empty for
+// auto, which picks the provider per request.
+func (c *clientFlags) modelFor(cfg *config.Config) string {
+	if c.model != "" {
+		return c.model
+	}
+	return cfg.Providers[cfg.ActiveProvider].Model
+}
+
+func (c *clientFlags) apply(cfg *config.Config) error {
+	if c.provider != "" {
+		if _, ok := cfg.Providers[c.provider]; !ok && c.provider != config.AutoProvider {
+			return fmt.Errorf("provider '%s' not found in providers list", c.provider)
 		}
-		fmt.Println()
-	} else if *structMode {
-		fmt.Println(">> STRUCTURED MODE ACTIVE...")
-		type ResponseStruct struct {
-			Answer   string   `json:"answer" description:"The direct answer to the question"`
-			Keywords []string `json:"keywords" description:"List of important keywords related to the answer"`
-			IsCode   bool     `json:"is_code" description:"Whether the answer contains code snippets"`
+		cfg.ActiveProvider = c.provider
+	}
+	if c.apiKey != "" {
+		if cfg.ActiveProvider == config.AutoProvider {
+			return errors.New("-k needs a single provider, not auto")
 		}
+		p := cfg.Providers[cfg.ActiveProvider]
+		p.APIKey = config.Secret(c.apiKey)
+		cfg.Providers[cfg.ActiveProvider] = p
+	}
 
-		var target ResponseStruct
-		err := ai.GenerateStruct(context.Background(), finalClient, req, &target)
-		if err != nil {
-			log.Fatalf("ERROR: %v", err)
-		}
-		fmt.Printf(">> RESPONSE STRUCT:\n%+v\n", target)
-	} else {
-		resp, err := finalClient.Generate(context.Background(), req)
-		if err != nil {
-			log.Fatalf("ERROR: %v", err)
+	overrides := map[string]map[string]interface{}{}
+	set := func(stage, key string, v interface{}) {
+		if overrides[stage] == nil {
+			overrides[stage] = map[string]interface{}{}
 		}
-		fmt.Println(">> RESPONSE:", resp.Content)
+		overrides[stage][key] = v
+	}
+	// Only flags given on the command line override the file, so an
+	// explicit 0 applies too.
+	given := map[string]bool{}
+	if c.fs != nil {
+		c.fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
 	}
+	var removed []string
 
-	fmt.Printf("\n------------------------------------------------")
-	fmt.Printf("\n Client Duration: %v (Logs might be written asynchronously in the background)\n", time.Since(start))
+	if given["retries"] {
+		set(config.StageRetry, "max_retries", c.retries)
+	}
+	if given["breaker-timeout"] {
+		set(config.StageCircuitBreaker, "reset_timeout", c.breakerTimeout.String())
+	}
+	switch {
+	case given["breaker-threshold"] && c.breakerThreshold == 0:
+		removed = append(removed, config.StageCircuitBreaker)
+	case given["breaker-threshold"]:
+		set(config.StageCircuitBreaker, "threshold", c.breakerThreshold)
+	}
+	switch {
+	case given["rate-limit"] && c.rateLimit == 0:
+		removed = append(removed, config.StageRateLimit)
+	case given["rate-limit"]:
+		set(config.StageRateLimit, "rps", c.rateLimit)
+	}
+	switch c.log {
+	case "":
+	case "off":
+		removed = append(removed, config.StageLogging)
+	case "on":
+		set(config.StageLogging, "errors_only", false)
+	case "errors":
+		set(config.StageLogging, "errors_only", true)
+	case "payloads":
+		set(config.StageLogging, "errors_only", false)
+		set(config.StageLogging, "payloads", true)
+	default:
+		return fmt.Errorf("-log must be on, errors, payloads or off, not %q", c.log)
+	}
 
-	time.Sleep(100 * time.Millisecond)
+	for stage, params := range overrides {
+		if err := cfg.OverrideStage(stage, params); err != nil {
+			return err
+		}
+	}
+	for _, stage := range removed {
+		cfg.RemoveStage(stage)
+	}
+	return cfg.Validate()
+}
+
+// logger writes telemetry as JSON to stderr, keeping stdout for replies.
+func (c *clientFlags) logger() logger.Logger {
+	if c.log == "off" {
+		return &logger.NoOpLogger{}
+	}
+	return logger.NewJSONLogger(os.Stderr)
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/config"
+)
+
+func TestClientFlagsOverrideConfig(t *testing.T) {
+	stages := func(args ...string) (string, *config.Config) {
+		t.Helper()
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		var cf clientFlags
+		cf.register(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := defaultConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cf.apply(cfg); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, s := range cfg.Pipeline {
+			names = append(names, s.Name)
+		}
+		return strings.Join(names, ","), cfg
+	}
+
+	if got, _ := stages(); got != "tracing,circuit_breaker,logging,retry,cost" {
+		t.Errorf("no flags should keep the file pipeline, got %s", got)
+	}
+	if got, _ := stages("-log", "off", "-breaker-threshold", "0", "-rate-limit", "0"); got != "tracing,retry,cost" {
+		t.Errorf("0 and off should remove stages, got %s", got)
+	}
+
+	_, cfg := stages("-retries", "0")
+	var retry config.RetryParams
+	if err := cfg.Pipeline[3].Decode(&retry); err != nil {
+		t.Fatal(err)
+	}
+	if retry.MaxRetries == nil || *retry.MaxRetries != 0 {
+		t.Errorf("-retries 0 should override the file, got %v", retry.MaxRetries)
+	}
+}
+
+func TestDefaultConfigTemperature(t *testing.T) {
+	cfg, err := defaultConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, p := range cfg.Providers {
+		if p.Temperature == nil || *p.Temperature != 0.7 {
+			t.Errorf("%s: temperature = %v, want 0.7", name, p.Temperature)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/config"
+)
+
+// runModels implements `gopoly models`. Without -p it lists the active
+// provider, or every candidate when the active provider is auto.
+func runModels(g *globals, args []string) int {
+	fs := flag.NewFlagSet("models", flag.ExitOnError)
+	g.register(fs)
+	provider := fs.String("p", "", "Provider from the config (default active_provider)")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	timeout := fs.Duration("timeout", 30*time.Second, "Timeout per provider")
+	fs.Parse(args)
+
+	cfg, err := g.load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	names := []string{*provider}
+	switch {
+	case *provider == "" && cfg.ActiveProvider == config.AutoProvider:
+		names = cfg.AutoCandidates()
+	case *provider == "":
+		names = []string{cfg.ActiveProvider}
+	}
+
+	type listing struct {
+		Provider string         `json:"provider"`
+		Models   []ai.ModelInfo `json:"models,omitempty"`
+		Error    string         `json:"error,omitempty"`
+	}
+	var listings []listing
+	failed := 0
+
+	for _, name := range names {
+		l := listing{Provider: name}
+		models, err := listModels(cfg, name, *timeout)
+		if err != nil {
+			l.Error = err.Error()
+			failed++
+		}
+		l.Models = models
+		listings = append(listings, l)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(listings)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROVIDER\tMODEL\tCONTEXT\tOWNER")
+		for _, l := range listings {
+			if l.Error != "" {
+				fmt.Fprintf(os.Stderr, "⚠️  %s: %s\n", l.Provider, l.Error)
+			}
+			for _, m := range l.Models {
+				window := "-"
+				if m.ContextWindow > 0 {
+					window = fmt.Sprint(m.ContextWindow)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", l.Provider, m.ID, window, m.OwnedBy)
+			}
+		}
+		w.Flush()
+	}
+
+	if failed == len(listings) {
+		return 1
+	}
+	return 0
+}
+
+func listModels(cfg *config.Config, name string, timeout time.Duration) ([]ai.ModelInfo, error) {
+	p, err := cfg.NewProvider(name)
+	if err != nil {
+		return nil, err
+	}
+	cp, ok := ai.As[ai.CapabilityProvider](p)
+	if !ok {
+		return nil, fmt.Errorf("provider '%s' cannot list models", name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return cp.ListModels(ctx)
+}
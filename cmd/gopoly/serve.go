@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/config"
+)
+
+// runServe implements `gopoly serve`: the configured pipeline behind a
+// small HTTP API, rebuilt when the config changes or on SIGHUP.
+func runServe(g *globals, args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	g.register(fs)
+	var cf clientFlags
+	cf.register(fs)
+	addr := fs.String("addr", "127.0.0.1:8080", "Listen address")
+	token := fs.String("token", os.Getenv(serveTokenEnv), "Bearer token required on /v1/generate (default $"+serveTokenEnv+")")
+	fs.Parse(args)
+
+	// The gateway spends the configured API keys for whoever can reach it,
+	// so it only listens beyond loopback behind a token.
+	if *token == "" && !isLoopback(*addr) {
+		fmt.Fprintf(os.Stderr, "ERROR: %s is reachable from other hosts; set -token or $%s\n", *addr, serveTokenEnv)
+		return 2
+	}
+
+	path := g.config
+	if path == "" {
+		path = "config.yaml"
+	}
+	w, err := config.NewWatcher(path, g.profile, func(cfg *config.Config) (ai.AIProvider, error) {
+		return cf.build(cfg)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go w.Run(ctx)
+
+	srv := &http.Server{Addr: *addr, Handler: newHandler(w.Provider, *token)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "--- 🚀 serving %s on %s ---\n", w.Provider().Name(), *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	closeProvider(w.Provider())
+	return 0
+}
+
+// streamEvent is one server-sent event of a streamed reply.
+type streamEvent struct {
+	Chunk string         `json:"chunk,omitempty"`
+	Usage *ai.TokenUsage `json:"usage,omitempty"`
+	Error string         `json:"error,omitempty"`
+}
+
+// serveTokenEnv holds the default for serve -token.
+const serveTokenEnv = "GOPOLY_SERVE_TOKEN"
+
+// isLoopback reports whether addr only accepts local connections. An empty
+// host listens on every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newHandler serves POST /v1/generate, which takes an ai.ChatRequest and
+// returns an ai.ChatResponse, or server-sent streamEvents when "stream" is
+// set, and GET /healthz. current is asked on every request so a reload
+// takes effect without restarting the server. A non-empty token must be
+// sent as "Authorization: Bearer <token>" on /v1/generate.
+func newHandler(current func() ai.AIProvider, token string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		if hr, ok := ai.As[ai.HealthReporter](current()); ok && !hr.Healthy() {
+			http.Error(w, "unhealthy", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.HandleFunc("POST /v1/generate", func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+				return
+			}
+		}
+
+		var req ai.ChatRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 10<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		// Extras can set arbitrary upstream headers; they stay a server-side
+		// setting.
+		req.Extras = nil
+		p := current()
+
+		if !req.Stream {
+			resp, err := p.Generate(r.Context(), req)
+			if err != nil {
+				writeError(w, statusFor(err), err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(resp)
+			return
+		}
+
+		stream, err := p.GenerateStream(r.Context(), req)
+		if err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		flusher, _ := w.(http.Flusher)
+
+		enc := json.NewEncoder(w)
+		for packet := range stream {
+			ev := streamEvent{Chunk: packet.Chunk, Usage: packet.Usage}
+			if packet.Err != nil {
+				ev.Error = packet.Err.Error()
+			}
+			if ev == (streamEvent{}) {
+				continue
+			}
+			fmt.Fprint(w, "data: ")
+			enc.Encode(ev)
+			fmt.Fprint(w, "\n")
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	return mux
+}
+
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, ai.ErrProviderDown), errors.Is(err, ai.ErrModelOverloaded):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ai.ErrContextExceeded):
+		return http.StatusBadRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
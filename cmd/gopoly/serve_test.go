@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+func TestServeHandler(t *testing.T) {
+	p := &echoProvider{name: "openai"}
+	h := newHandler(func() ai.AIProvider { return p }, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("healthz: got %d", rec.Code)
+	}
+
+	body := `{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/generate", strings.NewReader(body)))
+	got := rec.Body.String()
+	for _, want := range []string{`data: {"chunk":"openai says "}`, `"usage":{`, "data: [DONE]"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("stream missing %q:\n%s", want, got)
+		}
+	}
+	if p.last.Model != "gpt-4o" {
+		t.Errorf("model not forwarded: %q", p.last.Model)
+	}
+
+	// echoProvider.Generate always fails with ErrProviderDown.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/generate", strings.NewReader(`{"messages":[]}`)))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"error"`) {
+		t.Errorf("generate: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/generate", strings.NewReader("{")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("bad body: got %d", rec.Code)
+	}
+}
+
+func TestServeHandlerToken(t *testing.T) {
+	p := &echoProvider{name: "openai"}
+	h := newHandler(func() ai.AIProvider { return p }, "s3cret")
+	body := `{"stream":true,"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`
+
+	for _, auth := range []string{"", "Bearer wrong", "s3cret"} {
+		req := httptest.NewRequest("POST", "/v1/generate", strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: got %d", auth, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/v1/generate", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "data: [DONE]") {
+		t.Errorf("valid token: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Health checks stay open so load balancers need no secret.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("healthz: got %d", rec.Code)
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:8080": true,
+		"localhost:8080": true,
+		"[::1]:8080":     true,
+		":8080":          false,
+		"0.0.0.0:8080":   false,
+		"10.0.0.5:8080":  false,
+		"8080":           false,
+	} {
+		if got := isLoopback(addr); got != want {
+			t.Errorf("isLoopback(%q) = %v", addr, got)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Alias points a logical model name, such as "fast" or "vision", at a
+// provider and model. An empty Provider keeps the default provider; an
+// empty Model uses the provider's configured model, which is what
+// ResolveModel then reports.
+type Alias struct {
+	Provider string `yaml:"provider" json:"provider,omitempty"`
+	Model    string `yaml:"model" json:"model,omitempty"`
+}
+
+// Aliases maps logical model names to their targets.
+type Aliases map[string]Alias
+
+type aliasKey struct{}
+
+// WithModelAlias records the alias a request was made with. AliasRouter
+// sets it, so middleware below it can report both names.
+func WithModelAlias(ctx context.Context, alias string) context.Context {
+	return context.WithValue(ctx, aliasKey{}, alias)
+}
+
+// ModelAliasFrom returns the alias recorded by WithModelAlias, if any.
+func ModelAliasFrom(ctx context.Context) string {
+	alias, _ := ctx.Value(aliasKey{}).(string)
+	return alias
+}
+
+// ModelResolver is implemented by providers that rewrite model aliases, so
+// callers above them can record the model actually used.
+type ModelResolver interface {
+	ResolveModel(model string) string
+}
+
+// AliasRouter serves requests whose Model is an alias from the alias's
+// provider, with the model replaced by the alias's target. Other requests
+// go to the default provider unchanged.
+type AliasRouter struct {
+	def     AIProvider
+	aliases Aliases
+	members map[string]AIProvider
+}
+
+// NewAliasRouter routes aliases to members by provider name; aliases
+// without a provider use def.
+func NewAliasRouter(def AIProvider, aliases Aliases, members map[string]AIProvider) *AliasRouter {
+	return &AliasRouter{def: def, aliases: aliases, members: members}
+}
+
+func (r *AliasRouter) Configure(cfg Config) error {
+	return r.def.Configure(cfg)
+}
+
+func (r *AliasRouter) Name() string {
+	return r.def.Name()
+}
+
+func (r *AliasRouter) Unwrap() AIProvider {
+	return r.def
+}
+
+// Aliases returns the routing table.
+func (r *AliasRouter) Aliases() Aliases {
+	return r.aliases
+}
+
+// ResolveModel returns the model an alias resolves to; other names are
+// returned unchanged.
+func (r *AliasRouter) ResolveModel(model string) string {
+	a, ok := r.aliases[model]
+	if !ok {
+		return model
+	}
+	p := r.def
+	if m, ok := r.members[a.Provider]; ok {
+		p = m
+	}
+	return ModelOrDefault(p, a.Model)
+}
+
+func (r *AliasRouter) Generate(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	p, ctx, req, err := r.route(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Generate(ctx, req)
+}
+
+func (r *AliasRouter) GenerateStream(ctx context.Context, req ChatRequest) (<-chan StreamResponse, error) {
+	p, ctx, req, err := r.route(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.GenerateStream(ctx, req)
+}
+
+// Close releases the default provider and the members that hold resources.
+func (r *AliasRouter) Close() error {
+	providers := []AIProvider{r.def}
+	for _, p := range r.members {
+		if p != r.def {
+			providers = append(providers, p)
+		}
+	}
+
+	var errs []error
+	for _, p := range providers {
+		if c, ok := As[io.Closer](p); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *AliasRouter) route(ctx context.Context, req ChatRequest) (AIProvider, context.Context, ChatRequest, error) {
+	a, ok := r.aliases[req.Model]
+	if !ok {
+		return r.def, ctx, req, nil
+	}
+
+	p := r.def
+	if a.Provider != "" {
+		if p, ok = r.members[a.Provider]; !ok {
+			return nil, ctx, req, fmt.Errorf("model alias %q: provider %q is not configured", req.Model, a.Provider)
+		}
+	}
+	ctx = WithModelAlias(ctx, req.Model)
+	req.Model = a.Model
+	return p, ctx, req, nil
+}
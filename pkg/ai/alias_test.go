@@ -0,0 +1,128 @@
+package ai_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/conversation"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/logger"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/middleware"
+)
+
+type modelEcho struct{ name string }
+
+func (p *modelEcho) Configure(cfg ai.Config) error { return nil }
+func (p *modelEcho) Name() string                  { return p.name }
+func (p *modelEcho) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	return &ai.ChatResponse{Content: p.name + ":" + req.Model + ":" + ai.ModelAliasFrom(ctx)}, nil
+}
+func (p *modelEcho) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	ch := make(chan ai.StreamResponse, 1)
+	ch <- ai.StreamResponse{Chunk: p.name + ":" + req.Model}
+	close(ch)
+	return ch, nil
+}
+
+type entryRecorder struct {
+	mu      sync.Mutex
+	entries []logger.LogEntry
+}
+
+func (r *entryRecorder) Log(ctx context.Context, e logger.LogEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+}
+
+func TestAliasRouterResolvesModels(t *testing.T) {
+	def := &modelEcho{name: "openai"}
+	anthropic := &modelEcho{name: "anthropic"}
+	router := ai.NewAliasRouter(def, ai.Aliases{
+		"smart": {Provider: "anthropic", Model: "claude-3-5-sonnet-20240620"},
+		"fast":  {Model: "gpt-4o-mini"},
+		"gone":  {Provider: "mistral", Model: "large"},
+	}, map[string]ai.AIProvider{"openai": def, "anthropic": anthropic})
+
+	for model, want := range map[string]string{
+		"smart":  "anthropic:claude-3-5-sonnet-20240620:smart",
+		"fast":   "openai:gpt-4o-mini:fast",
+		"gpt-4o": "openai:gpt-4o:",
+	} {
+		resp, err := router.Generate(context.Background(), ai.ChatRequest{Model: model})
+		if err != nil || resp.Content != want {
+			t.Errorf("%s: got %v %v, want %s", model, resp, err, want)
+		}
+	}
+
+	if _, err := router.Generate(context.Background(), ai.ChatRequest{Model: "gone"}); err == nil {
+		t.Error("alias to an unconfigured provider should fail")
+	}
+
+	stream, err := router.GenerateStream(context.Background(), ai.ChatRequest{Model: "smart"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := (<-stream).Chunk; got != "anthropic:claude-3-5-sonnet-20240620" {
+		t.Errorf("stream routed to %s", got)
+	}
+
+	if r, ok := ai.As[ai.ModelResolver](middleware.NewTracingMiddleware(router)); !ok || r.ResolveModel("fast") != "gpt-4o-mini" {
+		t.Error("ResolveModel should be reachable through middleware")
+	}
+}
+
+func TestAliasRecordedInLogsAndTurns(t *testing.T) {
+	rec := &entryRecorder{}
+	logged := middleware.NewLoggingMiddleware(&modelEcho{name: "openai"}, rec, logger.Config{})
+	router := ai.NewAliasRouter(logged, ai.Aliases{"fast": {Model: "gpt-4o-mini"}}, nil)
+
+	conv := conversation.New(router, nil)
+	conv.SetModel("fast")
+	if _, err := conv.Send(context.Background(), "hi"); err != nil {
+		t.Fatal(err)
+	}
+
+	turns := conv.Turns()
+	last := turns[len(turns)-1]
+	if last.Model != "gpt-4o-mini" || last.Alias != "fast" {
+		t.Errorf("turn recorded model=%q alias=%q", last.Model, last.Alias)
+	}
+
+	time.Sleep(50 * time.Millisecond) // logging is asynchronous
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.entries) != 1 || rec.entries[0].Model != "gpt-4o-mini" || rec.entries[0].ModelAlias != "fast" {
+		t.Errorf("log entries: %+v", rec.entries)
+	}
+}
+
+// defaultModelEcho is a modelEcho with a configured default model.
+type defaultModelEcho struct {
+	modelEcho
+	model string
+}
+
+func (p *defaultModelEcho) DefaultModel() string { return p.model }
+
+func TestAliasWithoutModelUsesProviderDefault(t *testing.T) {
+	def := &modelEcho{name: "openai"}
+	local := &defaultModelEcho{modelEcho{name: "ollama"}, "llama3"}
+	priced := middleware.NewCostEstimator(local)
+	router := ai.NewAliasRouter(def, ai.Aliases{"local": {Provider: "ollama"}},
+		map[string]ai.AIProvider{"openai": def, "ollama": priced})
+
+	if got := router.ResolveModel("local"); got != "llama3" {
+		t.Errorf("ResolveModel(local) = %q, want the provider default", got)
+	}
+
+	resp, err := router.Generate(context.Background(), ai.ChatRequest{Model: "local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Usage.Model != "llama3" || resp.Usage.ModelAlias != "local" {
+		t.Errorf("cost entry recorded model=%q alias=%q", resp.Usage.Model, resp.Usage.ModelAlias)
+	}
+}
@@ -0,0 +1,113 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+var catalog = map[string]ai.Capabilities{
+	"claude-3":          {ContextWindow: 200_000, MaxOutputTokens: 4_096},
+	"claude-3-5-sonnet": {ContextWindow: 200_000, MaxOutputTokens: 8_192},
+	"claude-3-5-haiku":  {ContextWindow: 200_000, MaxOutputTokens: 8_192},
+}
+
+// Capabilities reflects this client rather than the raw API: only text parts
+// are forwarded, there is no JSON mode and the stream carries no usage.
+func (c *Client) Capabilities(ctx context.Context, model string) (ai.Capabilities, error) {
+	if model == "" {
+		model = c.settings.Load().Model
+	}
+	caps, _ := ai.LookupCapabilities(catalog, model)
+	caps.Input = []ai.ModelType{ai.ModelText}
+	caps.Output = []ai.ModelType{ai.ModelText}
+	caps.Tools = true
+	caps.Streaming = true
+	return caps, nil
+}
+
+// ListModels follows the endpoint's cursor pagination until has_more is
+// false.
+func (c *Client) ListModels(ctx context.Context) ([]ai.ModelInfo, error) {
+	s := c.settings.Load()
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderAnthropic)
+	extras := s.RequestExtras(ai.ChatRequest{}, ai.ProviderAnthropic)
+
+	var models []ai.ModelInfo
+	afterID := ""
+	for {
+		u := modelsURL(baseURL) + "?limit=1000"
+		if afterID != "" {
+			u += "&after_id=" + url.QueryEscape(afterID)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("x-api-key", apiKey)
+		httpReq.Header.Set("anthropic-version", "2023-06-01")
+		extras.ApplyHeaders(httpReq.Header)
+
+		resp, err := s.HTTPClient.Do(httpReq)
+		if err != nil {
+			return nil, ai.ErrProviderDown
+		}
+
+		var page struct {
+			Data []struct {
+				ID          string    `json:"id"`
+				DisplayName string    `json:"display_name"`
+				CreatedAt   time.Time `json:"created_at"`
+			} `json:"data"`
+			HasMore bool   `json:"has_more"`
+			LastID  string `json:"last_id"`
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("anthropic models status: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("json decode error: %w", err)
+		}
+
+		for _, m := range page.Data {
+			info := ai.ModelInfo{ID: m.ID, DisplayName: m.DisplayName, OwnedBy: "anthropic", Created: m.CreatedAt}
+			if caps, ok := ai.LookupCapabilities(catalog, m.ID); ok {
+				info.ContextWindow = caps.ContextWindow
+			}
+			models = append(models, info)
+		}
+
+		if !page.HasMore || page.LastID == "" {
+			return models, nil
+		}
+		afterID = page.LastID
+	}
+}
+
+func modelsURL(baseURL string) string {
+	root := strings.TrimSuffix(baseURL, "/")
+	if idx := strings.Index(root, "/messages"); idx != -1 {
+		root = root[:idx]
+	}
+	return root + "/models"
+}
+
+// HealthCheck pings the models endpoint, which validates the key and
+// reachability without generating tokens.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	_, err := c.ListModels(ctx)
+	return err
+}
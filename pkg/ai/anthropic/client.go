@@ -9,6 +9,8 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ahmettasdemir/gopolyai/pkg/ai"
@@ -16,49 +18,66 @@ import (
 
 const defaultBaseURL = "https://api.anthropic.com/v1/messages"
 
+// Client talks to the Messages API. Leading system messages are sent as
+// the top-level system prompt, and max_tokens, which the API requires,
+// defaults to 1024.
 type Client struct {
-	apiKey     string
-	model      string
-	baseURL    string
-	httpClient *http.Client
+	settings atomic.Pointer[ai.ClientSettings]
+	mu       sync.Mutex
 }
 
-func NewClient(apiKey string) *Client {
-	return &Client{
-		apiKey:  apiKey,
-		model:   "claude-3-5-sonnet-20240620",
-		baseURL: defaultBaseURL,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+// NewClient uses claude-3-5-sonnet-20240620 with a 60s timeout unless opts
+// say otherwise.
+func NewClient(apiKey string, opts ...ai.Option) *Client {
+	defaults := ai.ClientSettings{
+		APIKey:     apiKey,
+		Model:      "claude-3-5-sonnet-20240620",
+		BaseURL:    defaultBaseURL,
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
 	}
+	c := &Client{}
+	c.settings.Store(ai.NewClientSettings(defaults, opts...))
+	return c
+}
+
+// With returns a copy of c with opts applied, such as another model.
+func (c *Client) With(opts ...ai.Option) *Client {
+	next := &Client{}
+	next.settings.Store(c.settings.Load().With(opts...))
+	return next
 }
 
 func (c *Client) Configure(cfg ai.Config) error {
-	if cfg.APIKey != "" {
-		c.apiKey = cfg.APIKey
-	}
-	if cfg.ModelName != "" {
-		c.model = cfg.ModelName
-	}
-	if cfg.BaseURL != "" {
-		c.baseURL = cfg.BaseURL
-	}
-	if cfg.Timeout > 0 {
-		c.httpClient.Timeout = cfg.Timeout
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.settings.Store(c.settings.Load().With(cfg.Options()...))
 	return nil
 }
 
 func (c *Client) Name() string {
-	return "Anthropic Claude (" + c.model + ")"
+	return "Anthropic Claude (" + c.settings.Load().Model + ")"
+}
+
+func (c *Client) DefaultModel() string {
+	return c.settings.Load().Model
 }
 
 type claudeRequest struct {
-	Model     string          `json:"model"`
-	MaxTokens int             `json:"max_tokens"`
-	Messages  []claudeMessage `json:"messages"`
-	Temp      float64         `json:"temperature,omitempty"`
+	Model         string          `json:"model"`
+	MaxTokens     int             `json:"max_tokens"`
+	System        string          `json:"system,omitempty"`
+	Messages      []claudeMessage `json:"messages"`
+	Temp          *float64        `json:"temperature,omitempty"`
+	TopP          *float64        `json:"top_p,omitempty"`
+	TopK          *int            `json:"top_k,omitempty"`
+	StopSequences []string        `json:"stop_sequences,omitempty"`
+	Stream        bool            `json:"stream,omitempty"`
+}
+
+// The Messages API has no seed, penalties or logprobs.
+func unsupported(req ai.ChatRequest) []string {
+	return ai.UnsupportedParams(req, ai.ParamTemperature, ai.ParamTopP, ai.ParamTopK, ai.ParamStop)
 }
 
 type claudeMessage struct {
@@ -66,21 +85,47 @@ type claudeMessage struct {
 	Content string `json:"content"`
 }
 
-func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
-
-	var cMessages []claudeMessage
-	for _, msg := range req.Messages {
-		var textContent string
+// toClaude converts messages to the Messages API, which takes no "system"
+// role: the leading system messages become the top-level system prompt and
+// later ones are sent as user turns where they stand.
+func toClaude(msgs []ai.ChatMessage) (system string, out []claudeMessage) {
+	var prompts []string
+	leading := true
+	for _, msg := range msgs {
+		var text strings.Builder
 		for _, content := range msg.Content {
 			if content.Type == "text" {
-				textContent += content.Text
+				text.WriteString(content.Text)
+			}
+		}
+
+		role := msg.Role
+		if role == "system" {
+			if leading {
+				prompts = append(prompts, text.String())
+				continue
 			}
+			role = "user"
 		}
-		cMessages = append(cMessages, claudeMessage{
-			Role:    msg.Role,
-			Content: textContent,
-		})
+		leading = false
+		out = append(out, claudeMessage{Role: role, Content: text.String()})
 	}
+	return strings.Join(prompts, "\n\n"), out
+}
+
+// Generate emulates N candidates with concurrent calls; the Messages API has
+// no native n parameter and no logprobs.
+func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	return ai.GenerateN(ctx, req.N, func(ctx context.Context) (*ai.ChatResponse, error) {
+		return c.generateOnce(ctx, req)
+	})
+}
+
+func (c *Client) generateOnce(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	s := c.settings.Load()
+	req = s.ApplyDefaults(req)
+
+	system, cMessages := toClaude(req.Messages)
 
 	maxTokens := req.MaxTokens
 	if maxTokens == 0 {
@@ -88,26 +133,33 @@ func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResp
 	}
 
 	claudeReq := claudeRequest{
-		Model:     c.model,
-		MaxTokens: maxTokens,
-		Messages:  cMessages,
-		Temp:      req.Temperature,
+		Model:         s.ModelFor(req),
+		MaxTokens:     maxTokens,
+		System:        system,
+		Messages:      cMessages,
+		Temp:          req.Temperature,
+		TopP:          req.TopP,
+		TopK:          req.TopK,
+		StopSequences: req.Stop,
 	}
 
-	jsonData, err := json.Marshal(claudeReq)
+	extras := s.RequestExtras(req, ai.ProviderAnthropic)
+	jsonData, err := ai.MarshalWithExtras(claudeReq, extras)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewBuffer(jsonData))
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderAnthropic)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
-	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("x-api-key", apiKey)
 	httpReq.Header.Set("anthropic-version", "2023-06-01")
 	httpReq.Header.Set("Content-Type", "application/json")
+	extras.ApplyHeaders(httpReq.Header)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := s.HTTPClient.Do(httpReq)
 	if err != nil {
 		return nil, ai.ErrProviderDown
 	}
@@ -119,10 +171,14 @@ func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResp
 	}
 
 	var apiResp struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
 		Content []struct {
+			Type string `json:"type"`
 			Text string `json:"text"`
 		} `json:"content"`
-		Usage struct {
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
 			InputTokens  int `json:"input_tokens"`
 			OutputTokens int `json:"output_tokens"`
 		} `json:"usage"`
@@ -136,8 +192,29 @@ func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResp
 		return nil, fmt.Errorf("empty response from claude")
 	}
 
+	var text strings.Builder
+	for _, block := range apiResp.Content {
+		if block.Type == "" || block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	choice := ai.Choice{
+		Content:      text.String(),
+		FinishReason: stopReason(apiResp.StopReason),
+	}
+	if choice.FinishReason == ai.FinishContentFilter {
+		choice.Safety = []ai.SafetyRating{{Category: "refusal", Blocked: true}}
+	}
+
 	return &ai.ChatResponse{
-		Content: apiResp.Content[0].Text,
+		ID:           apiResp.ID,
+		Model:        apiResp.Model,
+		Content:      choice.Content,
+		FinishReason: choice.FinishReason,
+		Safety:       choice.Safety,
+		Choices:      []ai.Choice{choice},
+		Unsupported:  unsupported(req),
 		Usage: ai.TokenUsage{
 			InputTokens:  apiResp.Usage.InputTokens,
 			OutputTokens: apiResp.Usage.OutputTokens,
@@ -146,49 +223,64 @@ func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResp
 	}, nil
 }
 
+func stopReason(reason string) ai.FinishReason {
+	switch reason {
+	case "":
+		return ""
+	case "end_turn", "stop_sequence":
+		return ai.FinishStop
+	case "max_tokens":
+		return ai.FinishLength
+	case "tool_use":
+		return ai.FinishToolCalls
+	case "refusal":
+		return ai.FinishContentFilter
+	}
+	return ai.FinishOther
+}
+
 func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	s := c.settings.Load()
+	req = s.ApplyDefaults(req)
+
 	streamChan := make(chan ai.StreamResponse, 10)
 
-	var cMessages []claudeMessage
-	for _, msg := range req.Messages {
-		var textContent string
-		for _, content := range msg.Content {
-			if content.Type == "text" {
-				textContent += content.Text
-			}
-		}
-		cMessages = append(cMessages, claudeMessage{Role: msg.Role, Content: textContent})
-	}
+	system, cMessages := toClaude(req.Messages)
 
 	maxTokens := req.MaxTokens
 	if maxTokens == 0 {
 		maxTokens = 1024
 	}
 
-	claudeReq := map[string]interface{}{
-		"model":      c.model,
-		"max_tokens": maxTokens,
-		"messages":   cMessages,
-		"stream":     true,
-	}
-	if req.Temperature > 0 {
-		claudeReq["temperature"] = req.Temperature
+	claudeReq := claudeRequest{
+		Model:         s.ModelFor(req),
+		MaxTokens:     maxTokens,
+		System:        system,
+		Messages:      cMessages,
+		Temp:          req.Temperature,
+		TopP:          req.TopP,
+		TopK:          req.TopK,
+		StopSequences: req.Stop,
+		Stream:        true,
 	}
 
-	jsonData, err := json.Marshal(claudeReq)
+	extras := s.RequestExtras(req, ai.ProviderAnthropic)
+	jsonData, err := ai.MarshalWithExtras(claudeReq, extras)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewBuffer(jsonData))
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderAnthropic)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
-	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("x-api-key", apiKey)
 	httpReq.Header.Set("anthropic-version", "2023-06-01")
 	httpReq.Header.Set("Content-Type", "application/json")
+	extras.ApplyHeaders(httpReq.Header)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := s.HTTPClient.Do(httpReq)
 	if err != nil {
 		return nil, ai.ErrProviderDown
 	}
@@ -203,6 +295,10 @@ func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan
 		defer resp.Body.Close()
 		defer close(streamChan)
 
+		if skipped := unsupported(req); len(skipped) > 0 {
+			streamChan <- ai.StreamResponse{Unsupported: skipped}
+		}
+
 		var currentUsage ai.TokenUsage
 
 		scanner := bufio.NewScanner(resp.Body)
@@ -258,3 +354,9 @@ func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan
 
 	return streamChan, nil
 }
+
+func init() {
+	ai.Register(ai.ProviderAnthropic, func(opts ...ai.Option) (ai.AIProvider, error) {
+		return NewClient("", opts...), nil
+	})
+}
@@ -0,0 +1,19 @@
+package anthropic
+
+import (
+	"strings"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// Metadata sets metadata.user_id, which Anthropic uses for abuse detection.
+func Metadata(userID string) ai.Extras {
+	return ai.Extras{Body: map[string]interface{}{
+		"metadata": map[string]interface{}{"user_id": userID},
+	}}
+}
+
+// Beta opts into beta features via the anthropic-beta header.
+func Beta(features ...string) ai.Extras {
+	return ai.Extras{Headers: map[string]string{"anthropic-beta": strings.Join(features, ",")}}
+}
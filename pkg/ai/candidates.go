@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"context"
+	"sync"
+)
+
+// GenerateN emulates ChatRequest.N for providers that only return a single
+// candidate: it runs generate n times concurrently and merges the results
+// into one response with n choices and summed usage.
+func GenerateN(ctx context.Context, n int, generate func(ctx context.Context) (*ChatResponse, error)) (*ChatResponse, error) {
+	if n <= 1 {
+		return generate(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make([]*ChatResponse, n)
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := generate(ctx)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			responses[i] = resp
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	merged := *responses[0]
+	merged.Choices = nil
+	merged.Usage = TokenUsage{}
+	for i, r := range responses {
+		for _, c := range r.Choices {
+			c.Index = i
+			merged.Choices = append(merged.Choices, c)
+		}
+		merged.Usage.InputTokens += r.Usage.InputTokens
+		merged.Usage.OutputTokens += r.Usage.OutputTokens
+		merged.Usage.TotalTokens += r.Usage.TotalTokens
+		merged.Usage.CostUSD += r.Usage.CostUSD
+	}
+
+	return &merged, nil
+}
@@ -0,0 +1,81 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/anthropic"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/openai"
+)
+
+func TestOpenAIMultipleCandidates(t *testing.T) {
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "chatcmpl-123",
+			"model": "gpt-4o-2024-08-06",
+			"choices": [
+				{"index": 0, "message": {"content": "A"}, "finish_reason": "stop",
+				 "logprobs": {"content": [{"token": "A", "logprob": -0.1, "top_logprobs": [{"token": "B", "logprob": -2.3}]}]}},
+				{"index": 1, "message": {"content": "B"}, "finish_reason": "length"}
+			],
+			"usage": {"prompt_tokens": 5, "completion_tokens": 2, "total_tokens": 7}
+		}`))
+	}))
+	defer server.Close()
+
+	client := openai.NewClient("test-key")
+	client.Configure(ai.Config{BaseURL: server.URL})
+
+	resp, err := client.Generate(context.Background(), ai.ChatRequest{N: 2, Logprobs: true, TopLogprobs: 1})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if sent["n"] != float64(2) || sent["logprobs"] != true || sent["top_logprobs"] != float64(1) {
+		t.Errorf("candidate parameters not sent: %v", sent)
+	}
+	if resp.ID != "chatcmpl-123" || resp.Model != "gpt-4o-2024-08-06" {
+		t.Errorf("metadata not mapped: id=%s model=%s", resp.ID, resp.Model)
+	}
+	if len(resp.Choices) != 2 || resp.Choices[1].FinishReason != ai.FinishLength {
+		t.Fatalf("choices not mapped: %+v", resp.Choices)
+	}
+	if resp.Content != "A" || resp.FinishReason != ai.FinishStop {
+		t.Errorf("first choice not mirrored: %s / %s", resp.Content, resp.FinishReason)
+	}
+	lp := resp.Choices[0].Logprobs
+	if len(lp) != 1 || lp[0].Logprob != -0.1 || len(lp[0].TopLogprobs) != 1 {
+		t.Errorf("logprobs not mapped: %+v", lp)
+	}
+}
+
+func TestAnthropicEmulatesCandidates(t *testing.T) {
+	server := anthropic.StartMockServer()
+	defer server.Close()
+
+	client := anthropic.NewClient("test-key")
+	client.Configure(ai.Config{BaseURL: server.URL})
+
+	resp, err := client.Generate(context.Background(), ai.ChatRequest{N: 3})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(resp.Choices) != 3 {
+		t.Fatalf("expected 3 choices, got %d", len(resp.Choices))
+	}
+	for i, c := range resp.Choices {
+		if c.Index != i {
+			t.Errorf("choice %d has index %d", i, c.Index)
+		}
+	}
+	if resp.Usage.TotalTokens != 90 {
+		t.Errorf("expected summed usage of 90 tokens, got %d", resp.Usage.TotalTokens)
+	}
+}
@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Capabilities describes what a model accepts and which request features it
+// honors. ContextWindow and MaxOutputTokens are 0 when unknown.
+type Capabilities struct {
+	Model           string      `json:"model"`
+	Input           []ModelType `json:"input"`
+	Output          []ModelType `json:"output"`
+	Tools           bool        `json:"tools"`
+	JSONMode        bool        `json:"json_mode"`
+	Streaming       bool        `json:"streaming"`
+	StreamUsage     bool        `json:"stream_usage"`
+	ContextWindow   int         `json:"context_window,omitempty"`
+	MaxOutputTokens int         `json:"max_output_tokens,omitempty"`
+}
+
+// Accepts reports whether the model takes input of type m.
+func (c Capabilities) Accepts(m ModelType) bool {
+	for _, t := range c.Input {
+		if t == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Serves reports whether the model can handle req, streamed or not.
+func (c Capabilities) Serves(req ChatRequest, stream bool) bool {
+	for _, m := range RequestModalities(req) {
+		if !c.Accepts(m) {
+			return false
+		}
+	}
+	if req.JSONMode && !c.JSONMode {
+		return false
+	}
+	return !stream || c.Streaming
+}
+
+// ModelInfo is one entry returned by a vendor's models endpoint.
+type ModelInfo struct {
+	ID            string    `json:"id"`
+	DisplayName   string    `json:"display_name,omitempty"`
+	OwnedBy       string    `json:"owned_by,omitempty"`
+	Created       time.Time `json:"created,omitempty"`
+	ContextWindow int       `json:"context_window,omitempty"`
+}
+
+// CapabilityProvider is implemented by clients that can describe their
+// models. Reach it through middleware with As[CapabilityProvider].
+type CapabilityProvider interface {
+	Capabilities(ctx context.Context, model string) (Capabilities, error)
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+}
+
+// RequestModalities lists the input types used by req's messages.
+func RequestModalities(req ChatRequest) []ModelType {
+	var text, image bool
+	for _, msg := range req.Messages {
+		for _, c := range msg.Content {
+			switch {
+			case c.ImageURL != nil || c.Type == "image_url" || c.Type == "image":
+				image = true
+			default:
+				text = true
+			}
+		}
+	}
+
+	var out []ModelType
+	if text {
+		out = append(out, ModelText)
+	}
+	if image {
+		out = append(out, ModelImage)
+	}
+	return out
+}
+
+// LookupCapabilities returns the catalog entry whose key is the longest
+// prefix of model, with Model set to the requested name.
+func LookupCapabilities(catalog map[string]Capabilities, model string) (Capabilities, bool) {
+	best, bestLen, found := Capabilities{}, -1, false
+	for key, c := range catalog {
+		if strings.HasPrefix(model, key) && len(key) > bestLen {
+			best, bestLen, found = c, len(key), true
+		}
+	}
+	best.Model = model
+	return best, found
+}
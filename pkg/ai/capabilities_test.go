@@ -0,0 +1,104 @@
+package ai_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/anthropic"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/ollama"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/openai"
+)
+
+func TestCapabilitiesServes(t *testing.T) {
+	img := "data:image/png;base64,AAAA"
+	req := ai.ChatRequest{Messages: []ai.ChatMessage{{Role: "user", Content: []ai.Content{
+		{Type: "text", Text: "what is this?"},
+		{Type: "image_url", ImageURL: &img},
+	}}}}
+
+	textOnly := ai.Capabilities{Input: []ai.ModelType{ai.ModelText}, Streaming: true}
+	if textOnly.Serves(req, false) {
+		t.Error("text-only model should not serve an image request")
+	}
+	vision := ai.Capabilities{Input: []ai.ModelType{ai.ModelText, ai.ModelImage}}
+	if !vision.Serves(req, false) {
+		t.Error("vision model should serve an image request")
+	}
+	if vision.Serves(req, true) {
+		t.Error("non-streaming model should not serve a stream")
+	}
+}
+
+func TestOpenAICapabilitiesAndListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"data": [{"id": "gpt-4o-2024-08-06", "created": 1722814719, "owned_by": "system"}, {"id": "whisper-1"}]}`))
+	}))
+	defer server.Close()
+
+	client := openai.NewClient("k", ai.WithBaseURL(server.URL+"/v1/chat/completions"), ai.WithHTTPClient(server.Client()))
+
+	cp, ok := ai.As[ai.CapabilityProvider](client)
+	if !ok {
+		t.Fatal("openai client should be a CapabilityProvider")
+	}
+	caps, _ := cp.Capabilities(context.Background(), "gpt-4o-mini")
+	if !caps.Accepts(ai.ModelImage) || caps.ContextWindow != 128_000 || !caps.StreamUsage {
+		t.Errorf("unexpected gpt-4o-mini capabilities: %+v", caps)
+	}
+
+	models, err := cp.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(models) != 2 || models[0].ContextWindow != 128_000 || models[0].Created.Year() != 2024 {
+		t.Errorf("unexpected models: %+v", models)
+	}
+}
+
+func TestAnthropicListModelsPaginates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "k" {
+			t.Errorf("missing api key header")
+		}
+		if r.URL.Query().Get("after_id") == "" {
+			w.Write([]byte(`{"data": [{"id": "claude-3-5-sonnet-20241022", "display_name": "Claude 3.5 Sonnet"}], "has_more": true, "last_id": "claude-3-5-sonnet-20241022"}`))
+			return
+		}
+		w.Write([]byte(`{"data": [{"id": "claude-3-haiku-20240307"}], "has_more": false}`))
+	}))
+	defer server.Close()
+
+	client := anthropic.NewClient("k", ai.WithBaseURL(server.URL+"/v1/messages"), ai.WithHTTPClient(server.Client()))
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(models) != 2 || models[0].DisplayName != "Claude 3.5 Sonnet" || models[1].ContextWindow != 200_000 {
+		t.Errorf("unexpected models: %+v", models)
+	}
+}
+
+func TestOllamaCapabilitiesFromShow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/show" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"capabilities": ["completion", "vision"], "model_info": {"general.architecture": "mllama", "mllama.context_length": 131072}}`))
+	}))
+	defer server.Close()
+
+	client := ollama.NewClient(ai.WithBaseURL(server.URL+"/api/chat"), ai.WithHTTPClient(server.Client()))
+	caps, err := client.Capabilities(context.Background(), "llama3.2-vision")
+	if err != nil {
+		t.Fatalf("Capabilities failed: %v", err)
+	}
+	if !caps.Accepts(ai.ModelImage) || caps.Tools || caps.ContextWindow != 131_072 {
+		t.Errorf("unexpected capabilities: %+v", caps)
+	}
+}
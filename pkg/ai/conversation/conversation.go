@@ -0,0 +1,358 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/google/uuid"
+)
+
+// Conversation keeps the chat history for an AIProvider so callers only send
+// the new user message. Every completed turn is persisted to the Store.
+type Conversation struct {
+	provider ai.AIProvider
+	store    Store
+
+	mu      sync.Mutex
+	session *Session
+
+	// template supplies model and sampling settings for every call; its
+	// Messages are replaced by the conversation history.
+	template ai.ChatRequest
+}
+
+func New(p ai.AIProvider, store Store) *Conversation {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	now := time.Now()
+	return &Conversation{
+		provider: p,
+		store:    store,
+		session: &Session{
+			ID:        uuid.NewString(),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+}
+
+func Load(ctx context.Context, p ai.AIProvider, store Store, id string) (*Conversation, error) {
+	s, err := store.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conversation{provider: p, store: store, session: s}
+	if s.Template != nil {
+		c.template = *s.Template
+	}
+	return c, nil
+}
+
+func (c *Conversation) ID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.session.ID
+}
+
+func (c *Conversation) Provider() ai.AIProvider {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.provider
+}
+
+// SetProvider switches the backing provider; history is kept.
+func (c *Conversation) SetProvider(p ai.AIProvider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.provider = p
+}
+
+// Template returns the settings used for every call.
+func (c *Conversation) Template() ai.ChatRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.template
+}
+
+// SetTemplate replaces the model and sampling settings used for every call.
+// Its Messages are ignored. Like SetModel and SetTemperature, the change is
+// persisted with the next turn or Save.
+func (c *Conversation) SetTemplate(req ai.ChatRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.template = req
+}
+
+// SetModel sets the model for later calls; "" uses the provider default.
+func (c *Conversation) SetModel(model string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.template.Model = model
+}
+
+// SetTemperature sets the temperature for later calls; nil uses the
+// provider default.
+func (c *Conversation) SetTemperature(t *float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.template.Temperature = t
+}
+
+// SetSystem replaces the leading system prompt, or inserts one, and
+// persists the change.
+func (c *Conversation) SetSystem(ctx context.Context, prompt string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msg := ai.ChatMessage{Role: "system", Content: []ai.Content{{Type: "text", Text: prompt}}}
+	turns := c.session.Turns
+	if len(turns) > 0 && turns[0].Message.Role == "system" {
+		turns[0].Message = msg
+	} else {
+		c.session.Turns = append([]Turn{{Message: msg, CreatedAt: time.Now()}}, turns...)
+	}
+	return c.save(ctx)
+}
+
+func (c *Conversation) Turns() []Turn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Turn(nil), c.session.Turns...)
+}
+
+func (c *Conversation) Messages() []ai.ChatMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.messages()
+}
+
+func (c *Conversation) messages() []ai.ChatMessage {
+	msgs := make([]ai.ChatMessage, len(c.session.Turns))
+	for i, t := range c.session.Turns {
+		msgs[i] = t.Message
+	}
+	return msgs
+}
+
+// Usage sums token usage and cost over all assistant turns.
+func (c *Conversation) Usage() ai.TokenUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total ai.TokenUsage
+	for _, t := range c.session.Turns {
+		if t.Usage == nil {
+			continue
+		}
+		total.InputTokens += t.Usage.InputTokens
+		total.OutputTokens += t.Usage.OutputTokens
+		total.TotalTokens += t.Usage.TotalTokens
+		total.CostUSD += t.Usage.CostUSD
+	}
+	return total
+}
+
+// Reset drops every turn except the system prompt.
+func (c *Conversation) Reset(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var kept []Turn
+	if len(c.session.Turns) > 0 && c.session.Turns[0].Message.Role == "system" {
+		kept = c.session.Turns[:1]
+	}
+	c.session.Turns = kept
+	return c.save(ctx)
+}
+
+func (c *Conversation) Send(ctx context.Context, text string) (*ai.ChatResponse, error) {
+	return c.SendMessage(ctx, textMessage("user", text))
+}
+
+func (c *Conversation) SendMessage(ctx context.Context, msg ai.ChatMessage) (*ai.ChatResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.session.Turns = append(c.session.Turns, Turn{Message: msg, CreatedAt: time.Now()})
+	req := c.request()
+
+	start := time.Now()
+	resp, err := c.provider.Generate(ctx, req)
+	if err != nil {
+		c.session.Turns = c.session.Turns[:len(c.session.Turns)-1]
+		return nil, err
+	}
+
+	usage := resp.Usage
+	model, alias := resolve(c.provider, req.Model)
+	c.session.Turns = append(c.session.Turns, Turn{
+		Message:   textMessage("assistant", resp.Content),
+		Model:     model,
+		Alias:     alias,
+		Usage:     &usage,
+		Duration:  time.Since(start),
+		CreatedAt: time.Now(),
+	})
+
+	if err := c.save(ctx); err != nil {
+		return resp, fmt.Errorf("persist conversation: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *Conversation) SendStream(ctx context.Context, text string) (<-chan ai.StreamResponse, error) {
+	return c.SendMessageStream(ctx, textMessage("user", text))
+}
+
+// SendMessageStream records the user message and the reply once the
+// provider closes the stream without error; a failed or cancelled reply is
+// discarded along with its user message. The conversation is not held while
+// the reply streams, so a caller that stops reading blocks only its own
+// stream, and a send that overlaps it starts from the history without it.
+func (c *Conversation) SendMessageStream(ctx context.Context, msg ai.ChatMessage) (<-chan ai.StreamResponse, error) {
+	c.mu.Lock()
+	provider := c.provider
+	req := c.request()
+	c.mu.Unlock()
+	req.Messages = append(req.Messages, msg)
+	req.Stream = true
+
+	start := time.Now()
+	originalChan, err := provider.GenerateStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	proxyChan := make(chan ai.StreamResponse, 10)
+
+	go func() {
+		defer close(proxyChan)
+
+		var content strings.Builder
+		var usage *ai.TokenUsage
+		var streamErr error
+		cancelled := false
+
+		for packet := range originalChan {
+			// After a cancel the rest is drained so the provider can finish.
+			if cancelled {
+				continue
+			}
+			if packet.Err != nil {
+				streamErr = packet.Err
+			}
+			content.WriteString(packet.Chunk)
+			if packet.Usage != nil {
+				usage = packet.Usage
+			}
+			select {
+			case proxyChan <- packet:
+			case <-ctx.Done():
+				cancelled = true
+			}
+		}
+
+		// A provider may end the stream quietly on cancel; the reply is
+		// then incomplete.
+		if streamErr != nil || cancelled || ctx.Err() != nil {
+			return
+		}
+
+		model, alias := resolve(provider, req.Model)
+		reply := Turn{
+			Message:   textMessage("assistant", content.String()),
+			Model:     model,
+			Alias:     alias,
+			Usage:     usage,
+			Duration:  time.Since(start),
+			CreatedAt: time.Now(),
+		}
+		if err := c.commit(context.Background(), Turn{Message: msg, CreatedAt: start}, reply); err != nil {
+			select {
+			case proxyChan <- ai.StreamResponse{Err: fmt.Errorf("persist conversation: %w", err)}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return proxyChan, nil
+}
+
+// commit appends a completed exchange and persists the session.
+func (c *Conversation) commit(ctx context.Context, turns ...Turn) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.session.Turns = append(c.session.Turns, turns...)
+	return c.save(ctx)
+}
+
+// resolve returns the model a request for model was served by, and the
+// alias it was requested as, if p rewrites aliases.
+func resolve(p ai.AIProvider, model string) (resolved, alias string) {
+	if r, ok := ai.As[ai.ModelResolver](p); ok {
+		if m := r.ResolveModel(model); m != model {
+			return m, model
+		}
+	}
+	return model, ""
+}
+
+// Fork starts a new conversation containing the turns up to and including
+// index turn. The original conversation is unchanged.
+func (c *Conversation) Fork(ctx context.Context, turn int) (*Conversation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if turn < 0 || turn >= len(c.session.Turns) {
+		return nil, fmt.Errorf("fork: turn %d out of range (0-%d)", turn, len(c.session.Turns)-1)
+	}
+
+	now := time.Now()
+	forked := &Conversation{
+		provider: c.provider,
+		store:    c.store,
+		template: c.template,
+		session: &Session{
+			ID:        uuid.NewString(),
+			ParentID:  c.session.ID,
+			ForkedAt:  turn,
+			Turns:     append([]Turn(nil), c.session.Turns[:turn+1]...),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}
+
+	if err := forked.save(ctx); err != nil {
+		return nil, err
+	}
+	return forked, nil
+}
+
+func (c *Conversation) Save(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.save(ctx)
+}
+
+func (c *Conversation) save(ctx context.Context) error {
+	t := c.template
+	t.Messages, t.Extras = nil, nil
+	c.session.Template = &t
+	c.session.UpdatedAt = time.Now()
+	return c.store.Save(ctx, c.session)
+}
+
+func (c *Conversation) request() ai.ChatRequest {
+	req := c.template
+	req.Messages = c.messages()
+	return req
+}
+
+func textMessage(role, text string) ai.ChatMessage {
+	return ai.ChatMessage{Role: role, Content: []ai.Content{{Type: "text", Text: text}}}
+}
@@ -0,0 +1,210 @@
+package conversation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/mock"
+)
+
+type streamProvider struct {
+	*mock.MockClient
+}
+
+func (s *streamProvider) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	ch := make(chan ai.StreamResponse, 2)
+	ch <- ai.StreamResponse{Chunk: "Hello "}
+	ch <- ai.StreamResponse{Chunk: "World", Usage: &ai.TokenUsage{TotalTokens: 7}}
+	close(ch)
+	return ch, nil
+}
+
+func TestConversation_SendAppendsTurns(t *testing.T) {
+	conv := New(mock.NewClient("hi", false), nil)
+	conv.SetSystem(context.Background(), "be brief")
+
+	if _, err := conv.Send(context.Background(), "one"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if _, err := conv.Send(context.Background(), "two"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	msgs := conv.Messages()
+	if len(msgs) != 5 {
+		t.Fatalf("expected 5 messages, got %d", len(msgs))
+	}
+	if msgs[4].Role != "assistant" || msgs[4].Content[0].Text != "MOCK: hi" {
+		t.Errorf("unexpected last message: %+v", msgs[4])
+	}
+	if usage := conv.Usage(); usage.TotalTokens != 70 {
+		t.Errorf("expected 70 total tokens, got %d", usage.TotalTokens)
+	}
+}
+
+func TestConversation_SendStream(t *testing.T) {
+	conv := New(&streamProvider{mock.NewClient("", false)}, nil)
+
+	stream, err := conv.SendStream(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("SendStream failed: %v", err)
+	}
+	for range stream {
+	}
+
+	turns := conv.Turns()
+	if len(turns) != 2 || turns[1].Message.Content[0].Text != "Hello World" {
+		t.Fatalf("stream reply not recorded: %+v", turns)
+	}
+	if turns[1].Usage == nil || turns[1].Usage.TotalTokens != 7 {
+		t.Error("stream usage not recorded")
+	}
+}
+
+func TestConversation_FileStoreAndFork(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := mock.NewClient("hi", false)
+	conv := New(provider, store)
+	conv.Send(context.Background(), "one")
+	conv.Send(context.Background(), "two")
+
+	loaded, err := Load(context.Background(), provider, store, conv.ID())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded.Turns()) != 4 {
+		t.Fatalf("expected 4 persisted turns, got %d", len(loaded.Turns()))
+	}
+
+	fork, err := loaded.Fork(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Fork failed: %v", err)
+	}
+	fork.Send(context.Background(), "three")
+
+	if len(fork.Turns()) != 4 {
+		t.Errorf("expected fork to have 4 turns, got %d", len(fork.Turns()))
+	}
+	if len(loaded.Turns()) != 4 {
+		t.Error("fork modified the original conversation")
+	}
+
+	ids, _ := store.List(context.Background())
+	if len(ids) != 2 {
+		t.Errorf("expected 2 stored sessions, got %d", len(ids))
+	}
+}
+
+// endlessProvider streams until the request is cancelled.
+type endlessProvider struct {
+	*mock.MockClient
+}
+
+func (e *endlessProvider) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	ch := make(chan ai.StreamResponse)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case ch <- ai.StreamResponse{Chunk: "more "}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func TestConversation_CancelledStreamReleasesConversation(t *testing.T) {
+	conv := New(&endlessProvider{mock.NewClient("", false)}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := conv.SendStream(ctx, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-stream
+	cancel() // stop reading without draining
+
+	done := make(chan []Turn)
+	go func() { done <- conv.Turns() }()
+	select {
+	case turns := <-done:
+		if len(turns) != 0 {
+			t.Errorf("cancelled turn kept: %+v", turns)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("conversation still locked after cancel")
+	}
+}
+
+func TestConversation_SetSystemPersists(t *testing.T) {
+	store := NewMemoryStore()
+	conv := New(mock.NewClient("hi", false), store)
+	if err := conv.SetSystem(context.Background(), "be brief"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(context.Background(), nil, store, conv.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if turns := loaded.Turns(); len(turns) != 1 || turns[0].Message.Content[0].Text != "be brief" {
+		t.Errorf("system prompt not persisted: %+v", turns)
+	}
+}
+
+func TestConversation_AbandonedStreamDoesNotBlock(t *testing.T) {
+	conv := New(&endlessProvider{mock.NewClient("hi", false)}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := conv.SendStream(ctx, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-stream // stop reading, without cancelling
+
+	done := make(chan error)
+	go func() {
+		_, err := conv.Send(context.Background(), "again")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("conversation blocked by an unread stream")
+	}
+	if turns := conv.Turns(); len(turns) != 2 || turns[0].Message.Content[0].Text != "again" {
+		t.Errorf("unfinished stream leaked into the history: %+v", turns)
+	}
+}
+
+func TestConversation_LoadRestoresTemplate(t *testing.T) {
+	store := NewMemoryStore()
+	conv := New(mock.NewClient("hi", false), store)
+	conv.SetModel("gpt-4o")
+	conv.SetTemperature(ai.Float64(0))
+	conv.SetSystem(context.Background(), "be brief")
+
+	loaded, err := Load(context.Background(), nil, store, conv.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := loaded.Template()
+	if tmpl.Model != "gpt-4o" || tmpl.Temperature == nil || *tmpl.Temperature != 0 {
+		t.Errorf("template not restored: %+v", tmpl)
+	}
+	if msgs := loaded.Messages(); len(msgs) != 1 || msgs[0].Role != "system" {
+		t.Errorf("system prompt not restored: %+v", msgs)
+	}
+}
@@ -0,0 +1,32 @@
+package ai
+
+import "context"
+
+// Credentials override a client's configured API key and base URL for a
+// single call, e.g. for bring-your-own-key tenants. Empty fields keep the
+// client's value.
+type Credentials struct {
+	APIKey  string
+	BaseURL string
+}
+
+type credentialsKey struct{}
+
+// WithCredentials attaches credentials for one provider to ctx. They are
+// keyed by provider name so a fallback to another vendor never receives a
+// foreign key.
+func WithCredentials(ctx context.Context, provider string, creds Credentials) context.Context {
+	existing, _ := ctx.Value(credentialsKey{}).(map[string]Credentials)
+	next := make(map[string]Credentials, len(existing)+1)
+	for k, v := range existing {
+		next[k] = v
+	}
+	next[provider] = creds
+	return context.WithValue(ctx, credentialsKey{}, next)
+}
+
+func CredentialsFrom(ctx context.Context, provider string) (Credentials, bool) {
+	all, _ := ctx.Value(credentialsKey{}).(map[string]Credentials)
+	creds, ok := all[provider]
+	return creds, ok
+}
@@ -0,0 +1,55 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/anthropic"
+)
+
+func TestAnthropicPerRequestOverrides(t *testing.T) {
+	var gotKey, gotModel string
+	tenant := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("x-api-key")
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotModel, _ = body["model"].(string)
+		w.Write([]byte(`{"content": [{"type": "text", "text": "tenant"}]}`))
+	}))
+	defer tenant.Close()
+
+	shared := anthropic.StartMockServer()
+	defer shared.Close()
+
+	client := anthropic.NewClient("shared-key")
+	client.Configure(ai.Config{BaseURL: shared.URL})
+
+	ctx := ai.WithCredentials(context.Background(), ai.ProviderAnthropic, ai.Credentials{
+		APIKey:  "tenant-key",
+		BaseURL: tenant.URL,
+	})
+	ctx = ai.WithCredentials(ctx, ai.ProviderOpenAI, ai.Credentials{APIKey: "sk-other"})
+
+	resp, err := client.Generate(ctx, ai.ChatRequest{Model: "claude-3-haiku-20240307"})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if resp.Content != "tenant" || gotKey != "tenant-key" {
+		t.Errorf("per-request credentials not used: content=%q key=%q", resp.Content, gotKey)
+	}
+	if gotModel != "claude-3-haiku-20240307" {
+		t.Errorf("request model ignored, sent %q", gotModel)
+	}
+
+	resp, err = client.Generate(context.Background(), ai.ChatRequest{})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if resp.Content != "This is a mock response from Anthropic Claude." {
+		t.Errorf("shared client was mutated by per-request override: %q", resp.Content)
+	}
+}
@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+)
+
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderGoogle    = "google"
+	ProviderOllama    = "ollama"
+)
+
+// Extras carries vendor knobs gopolyai does not model. Body is deep-merged
+// into the outgoing JSON request and Headers are set on the HTTP request.
+type Extras struct {
+	Body    map[string]interface{} `json:"body,omitempty" yaml:"body"`
+	Headers map[string]string      `json:"headers,omitempty" yaml:"headers"`
+}
+
+// ProviderExtras keys Extras by provider name, so a request routed through
+// a fallback chain only hands each vendor its own options.
+type ProviderExtras map[string]Extras
+
+func (p ProviderExtras) For(provider string) Extras {
+	return p[provider]
+}
+
+func (e Extras) IsEmpty() bool {
+	return len(e.Body) == 0 && len(e.Headers) == 0
+}
+
+// MergeExtras layers extras left to right; later values win.
+func MergeExtras(layers ...Extras) Extras {
+	var out Extras
+	for _, l := range layers {
+		if len(l.Body) > 0 {
+			if out.Body == nil {
+				out.Body = make(map[string]interface{})
+			}
+			deepMerge(out.Body, l.Body)
+		}
+		if len(l.Headers) > 0 {
+			if out.Headers == nil {
+				out.Headers = make(map[string]string)
+			}
+			for k, v := range l.Headers {
+				out.Headers[k] = v
+			}
+		}
+	}
+	return out
+}
+
+// ApplyHeaders sets e.Headers on h. Credential headers the client already
+// set are kept, so extras cannot replace the configured key.
+func (e Extras) ApplyHeaders(h http.Header) {
+	for k, v := range e.Headers {
+		if h.Get(k) != "" && slices.Contains(sensitiveHeaders, http.CanonicalHeaderKey(k)) {
+			continue
+		}
+		h.Set(k, v)
+	}
+}
+
+// MarshalWithExtras encodes body and merges e.Body into the top-level JSON
+// object. Extras take precedence over fields set by the client.
+func MarshalWithExtras(body interface{}, e Extras) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil || len(e.Body) == 0 {
+		return data, err
+	}
+
+	merged, err := decodeObject(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-trip the extras too so typed values merge with decoded ones.
+	extraData, err := json.Marshal(e.Body)
+	if err != nil {
+		return nil, err
+	}
+	extra, err := decodeObject(extraData)
+	if err != nil {
+		return nil, err
+	}
+
+	deepMerge(merged, extra)
+	return json.Marshal(merged)
+}
+
+// decodeObject keeps numbers as json.Number so large integers such as
+// seeds survive the round trip.
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var m map[string]interface{}
+	err := dec.Decode(&m)
+	return m, err
+}
+
+func deepMerge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			deepMerge(dstMap, srcMap)
+			continue
+		}
+		if srcIsMap {
+			cp := make(map[string]interface{}, len(srcMap))
+			deepMerge(cp, srcMap)
+			dst[k] = cp
+			continue
+		}
+		dst[k] = v
+	}
+}
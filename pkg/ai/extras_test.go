@@ -0,0 +1,91 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/ollama"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/openai"
+)
+
+func TestOpenAIExtras(t *testing.T) {
+	var sent map[string]interface{}
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client := openai.NewClient("test-key")
+	client.Configure(ai.Config{BaseURL: server.URL, Extras: openai.Organization("org-1")})
+
+	req := ai.ChatRequest{
+		Extras: ai.ProviderExtras{
+			ai.ProviderOpenAI: openai.User("user-42"),
+			ai.ProviderOllama: ollama.KeepAlive("5m"),
+		},
+	}
+	if _, err := client.Generate(context.Background(), req); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if sent["user"] != "user-42" {
+		t.Errorf("request extras not merged: %v", sent)
+	}
+	if _, ok := sent["keep_alive"]; ok {
+		t.Error("extras for another provider leaked into the request")
+	}
+	if header.Get("OpenAI-Organization") != "org-1" {
+		t.Errorf("config header extras not applied: %v", header)
+	}
+
+	req.Extras = ai.ProviderExtras{ai.ProviderOpenAI: {Headers: map[string]string{"authorization": "Bearer stolen"}}}
+	client.Generate(context.Background(), req)
+	if got := header.Get("Authorization"); got != "Bearer test-key" {
+		t.Errorf("extras replaced the credentials: %q", got)
+	}
+}
+
+func TestMarshalWithExtrasDeepMerge(t *testing.T) {
+	body := map[string]interface{}{
+		"model":   "llama3",
+		"options": map[string]interface{}{"temperature": 0.2},
+	}
+	extras := ai.MergeExtras(ollama.NumCtx(8192), ollama.KeepAlive("10m"))
+
+	data, err := ai.MarshalWithExtras(body, extras)
+	if err != nil {
+		t.Fatalf("MarshalWithExtras failed: %v", err)
+	}
+
+	var got map[string]interface{}
+	json.Unmarshal(data, &got)
+
+	opts := got["options"].(map[string]interface{})
+	if opts["temperature"] != 0.2 || opts["num_ctx"] != float64(8192) {
+		t.Errorf("options not deep merged: %v", opts)
+	}
+	if got["keep_alive"] != "10m" {
+		t.Errorf("keep_alive missing: %v", got)
+	}
+}
+
+func TestMarshalWithExtrasKeepsLargeIntegers(t *testing.T) {
+	body := map[string]interface{}{"seed": int64(9007199254740993)}
+	extras := ai.Extras{Body: map[string]interface{}{"metadata": map[string]interface{}{"id": uint64(18446744073709551615)}}}
+
+	data, err := ai.MarshalWithExtras(body, extras)
+	if err != nil {
+		t.Fatalf("MarshalWithExtras failed: %v", err)
+	}
+	want := `{"metadata":{"id":18446744073709551615},"seed":9007199254740993}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
@@ -31,6 +31,10 @@ func (f *FallbackClient) Name() string {
 
 // UPDATED: Takes ChatRequest instead of String, returns ChatResponse
 func (f *FallbackClient) Generate(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if !healthy(f.Primary) {
+		return f.Secondary.Generate(ctx, req)
+	}
+
 	fmt.Printf("[Fallback] Trying %s...\n", f.Primary.Name())
 
 	resp, err := f.Primary.Generate(ctx, req)
@@ -50,6 +54,22 @@ func (f *FallbackClient) Generate(ctx context.Context, req ChatRequest) (*ChatRe
 
 // UPDATED: Stream signature also changed
 func (f *FallbackClient) GenerateStream(ctx context.Context, req ChatRequest) (<-chan StreamResponse, error) {
-	// Simple switch for now, will detail later
-	return f.Primary.GenerateStream(ctx, req)
+	if !healthy(f.Primary) {
+		return f.Secondary.GenerateStream(ctx, req)
+	}
+
+	stream, err := f.Primary.GenerateStream(ctx, req)
+	if err == nil {
+		return stream, nil
+	}
+	return f.Secondary.GenerateStream(ctx, req)
+}
+
+// healthy consults a HealthReporter in p's chain, if any; providers
+// without one are assumed healthy.
+func healthy(p AIProvider) bool {
+	if r, ok := As[HealthReporter](p); ok {
+		return r.Healthy()
+	}
+	return true
 }
@@ -0,0 +1,117 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+var catalog = map[string]ai.Capabilities{
+	"gemini-1.5-pro":   {ContextWindow: 2_000_000, MaxOutputTokens: 8_192},
+	"gemini-1.5-flash": {ContextWindow: 1_000_000, MaxOutputTokens: 8_192},
+	"gemini-2.0-flash": {ContextWindow: 1_048_576, MaxOutputTokens: 8_192},
+	"gemini-1.0-pro":   {ContextWindow: 30_720, MaxOutputTokens: 2_048},
+}
+
+// Capabilities reflects this client: Gemini models are multimodal, but only
+// text parts are forwarded today.
+func (c *Client) Capabilities(ctx context.Context, model string) (ai.Capabilities, error) {
+	if model == "" {
+		model = c.settings.Load().Model
+	}
+	caps, _ := ai.LookupCapabilities(catalog, model)
+	caps.Input = []ai.ModelType{ai.ModelText}
+	caps.Output = []ai.ModelType{ai.ModelText}
+	caps.Tools = true
+	caps.JSONMode = true
+	caps.Streaming = true
+	caps.StreamUsage = true
+	return caps, nil
+}
+
+// ListModels returns the models that support generateContent, following
+// nextPageToken. Context windows come from the API's inputTokenLimit.
+func (c *Client) ListModels(ctx context.Context) ([]ai.ModelInfo, error) {
+	s := c.settings.Load()
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderGoogle)
+	listURL := strings.Replace(fmt.Sprintf(baseURL, "", apiKey), "/:generateContent", "", 1)
+
+	var models []ai.ModelInfo
+	pageToken := ""
+	for {
+		u := listURL + "&pageSize=1000"
+		if pageToken != "" {
+			u += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := s.HTTPClient.Do(httpReq)
+		if err != nil {
+			return nil, ai.ErrProviderDown
+		}
+
+		var page struct {
+			Models []struct {
+				Name                       string   `json:"name"`
+				DisplayName                string   `json:"displayName"`
+				InputTokenLimit            int      `json:"inputTokenLimit"`
+				SupportedGenerationMethods []string `json:"supportedGenerationMethods"`
+			} `json:"models"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("google models status: %d, body: %s", resp.StatusCode, string(bodyBytes))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("json decode error: %w", err)
+		}
+
+		for _, m := range page.Models {
+			if !contains(m.SupportedGenerationMethods, "generateContent") {
+				continue
+			}
+			models = append(models, ai.ModelInfo{
+				ID:            strings.TrimPrefix(m.Name, "models/"),
+				DisplayName:   m.DisplayName,
+				OwnedBy:       "google",
+				ContextWindow: m.InputTokenLimit,
+			})
+		}
+
+		if page.NextPageToken == "" {
+			return models, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthCheck pings the models endpoint, which validates the key and
+// reachability without generating tokens.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	_, err := c.ListModels(ctx)
+	return err
+}
@@ -5,8 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ahmettasdemir/gopolyai/pkg/ai"
@@ -14,42 +17,49 @@ import (
 
 const baseURL = "https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s"
 
+// Client talks to the Gemini generateContent API. BaseURL is a format
+// string that takes the model and the API key, which Gemini expects as a
+// query parameter.
 type Client struct {
-	apiKey     string
-	model      string
-	baseURL    string
-	httpClient *http.Client
+	settings atomic.Pointer[ai.ClientSettings]
+	mu       sync.Mutex
 }
 
-func NewClient(apiKey string) *Client {
-	return &Client{
-		apiKey:  apiKey,
-		model:   "gemini-1.5-flash",
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+// NewClient uses gemini-1.5-flash with a 60s timeout unless opts say
+// otherwise.
+func NewClient(apiKey string, opts ...ai.Option) *Client {
+	defaults := ai.ClientSettings{
+		APIKey:     apiKey,
+		Model:      "gemini-1.5-flash",
+		BaseURL:    baseURL,
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
 	}
+	c := &Client{}
+	c.settings.Store(ai.NewClientSettings(defaults, opts...))
+	return c
+}
+
+// With returns a copy of c with opts applied.
+func (c *Client) With(opts ...ai.Option) *Client {
+	next := &Client{}
+	next.settings.Store(c.settings.Load().With(opts...))
+	return next
 }
 
 func (c *Client) Configure(cfg ai.Config) error {
-	if cfg.APIKey != "" {
-		c.apiKey = cfg.APIKey
-	}
-	if cfg.ModelName != "" {
-		c.model = cfg.ModelName
-	}
-	if cfg.BaseURL != "" {
-		c.baseURL = cfg.BaseURL
-	}
-	if cfg.Timeout > 0 {
-		c.httpClient.Timeout = cfg.Timeout
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.settings.Store(c.settings.Load().With(cfg.Options()...))
 	return nil
 }
 
 func (c *Client) Name() string {
-	return "Google Gemini (" + c.model + ")"
+	return "Google Gemini (" + c.settings.Load().Model + ")"
+}
+
+func (c *Client) DefaultModel() string {
+	return c.settings.Load().Model
 }
 
 type geminiRequest struct {
@@ -67,15 +77,41 @@ type geminiPart struct {
 }
 
 type genConfig struct {
-	Temperature     float64 `json:"temperature,omitempty"`
-	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"topP,omitempty"`
+	TopK             *int     `json:"topK,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	Seed             *int64   `json:"seed,omitempty"`
+	PresencePenalty  *float64 `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"`
+	MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+	CandidateCount   int      `json:"candidateCount,omitempty"`
+	ResponseLogprobs bool     `json:"responseLogprobs,omitempty"`
+	Logprobs         int      `json:"logprobs,omitempty"`
+	ResponseMimeType string   `json:"responseMimeType,omitempty"`
 }
 
-func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+func toGenConfig(req ai.ChatRequest) genConfig {
+	cfg := genConfig{
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		TopK:             req.TopK,
+		StopSequences:    req.Stop,
+		Seed:             req.Seed,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		MaxOutputTokens:  req.MaxTokens,
+	}
+	if req.JSONMode {
+		cfg.ResponseMimeType = "application/json"
+	}
+	return cfg
+}
 
+func toGeminiContents(messages []ai.ChatMessage) []geminiContent {
 	var gContents []geminiContent
 
-	for _, msg := range req.Messages {
+	for _, msg := range messages {
 		role := "user"
 		if msg.Role == "assistant" {
 			role = "model"
@@ -94,44 +130,72 @@ func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResp
 		})
 	}
 
+	return gContents
+}
+
+func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	s := c.settings.Load()
+	req = s.ApplyDefaults(req)
+
 	geminiReq := geminiRequest{
-		Contents: gContents,
-		GenerationConfig: genConfig{
-			Temperature:     req.Temperature,
-			MaxOutputTokens: req.MaxTokens,
-		},
+		Contents:         toGeminiContents(req.Messages),
+		GenerationConfig: toGenConfig(req),
+	}
+	geminiReq.GenerationConfig.ResponseLogprobs = req.Logprobs
+	geminiReq.GenerationConfig.Logprobs = req.TopLogprobs
+	if req.N > 1 {
+		geminiReq.GenerationConfig.CandidateCount = req.N
 	}
 
-	jsonData, err := json.Marshal(geminiReq)
+	extras := s.RequestExtras(req, ai.ProviderGoogle)
+	jsonData, err := ai.MarshalWithExtras(geminiReq, extras)
 	if err != nil {
 		return nil, err
 	}
 
-	url := fmt.Sprintf(c.baseURL, c.model, c.apiKey)
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderGoogle)
+	url := fmt.Sprintf(baseURL, s.ModelFor(req), apiKey)
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	extras.ApplyHeaders(httpReq.Header)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := s.HTTPClient.Do(httpReq)
 	if err != nil {
 		return nil, ai.ErrProviderDown
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("google api status: %d", resp.StatusCode)
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("google api status: %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	var apiResp struct {
-		Candidates []struct {
+		ResponseID   string `json:"responseId"`
+		ModelVersion string `json:"modelVersion"`
+		Candidates   []struct {
+			Index   int `json:"index"`
 			Content struct {
 				Parts []struct {
 					Text string `json:"text"`
 				} `json:"parts"`
 			} `json:"content"`
+			FinishReason   string         `json:"finishReason"`
+			SafetyRatings  []safetyRating `json:"safetyRatings"`
+			LogprobsResult *struct {
+				TopCandidates []struct {
+					Candidates []logprobCandidate `json:"candidates"`
+				} `json:"topCandidates"`
+				ChosenCandidates []logprobCandidate `json:"chosenCandidates"`
+			} `json:"logprobsResult"`
 		} `json:"candidates"`
+		PromptFeedback *struct {
+			BlockReason   string         `json:"blockReason"`
+			SafetyRatings []safetyRating `json:"safetyRatings"`
+		} `json:"promptFeedback"`
 		UsageMetadata struct {
 			PromptTokenCount     int `json:"promptTokenCount"`
 			CandidatesTokenCount int `json:"candidatesTokenCount"`
@@ -143,52 +207,108 @@ func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResp
 		return nil, err
 	}
 
-	if len(apiResp.Candidates) == 0 || len(apiResp.Candidates[0].Content.Parts) == 0 {
+	if len(apiResp.Candidates) == 0 {
+		if apiResp.PromptFeedback != nil && apiResp.PromptFeedback.BlockReason != "" {
+			return nil, fmt.Errorf("google blocked prompt: %s", apiResp.PromptFeedback.BlockReason)
+		}
 		return nil, fmt.Errorf("empty response from google")
 	}
 
-	return &ai.ChatResponse{
-		Content: apiResp.Candidates[0].Content.Parts[0].Text,
+	result := &ai.ChatResponse{
+		ID:    apiResp.ResponseID,
+		Model: apiResp.ModelVersion,
 		Usage: ai.TokenUsage{
 			InputTokens:  apiResp.UsageMetadata.PromptTokenCount,
 			OutputTokens: apiResp.UsageMetadata.CandidatesTokenCount,
 			TotalTokens:  apiResp.UsageMetadata.TotalTokenCount,
 		},
-	}, nil
-}
+	}
 
-func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
-	streamChan := make(chan ai.StreamResponse, 10)
+	for _, cand := range apiResp.Candidates {
+		var text strings.Builder
+		for _, part := range cand.Content.Parts {
+			text.WriteString(part.Text)
+		}
 
-	var gContents []geminiContent
-	for _, msg := range req.Messages {
-		role := "user"
-		if msg.Role == "assistant" {
-			role = "model"
+		choice := ai.Choice{
+			Index:        cand.Index,
+			Content:      text.String(),
+			FinishReason: finishReason(cand.FinishReason),
 		}
-		var textContent string
-		for _, content := range msg.Content {
-			if content.Type == "text" {
-				textContent += content.Text
+		for _, r := range cand.SafetyRatings {
+			choice.Safety = append(choice.Safety, ai.SafetyRating{Category: r.Category, Probability: r.Probability, Blocked: r.Blocked})
+		}
+		if lp := cand.LogprobsResult; lp != nil {
+			for i, chosen := range lp.ChosenCandidates {
+				tl := ai.TokenLogprob{Token: chosen.Token, Logprob: chosen.LogProbability}
+				if i < len(lp.TopCandidates) {
+					for _, top := range lp.TopCandidates[i].Candidates {
+						tl.TopLogprobs = append(tl.TopLogprobs, ai.TopLogprob{Token: top.Token, Logprob: top.LogProbability})
+					}
+				}
+				choice.Logprobs = append(choice.Logprobs, tl)
 			}
 		}
-		gContents = append(gContents, geminiContent{Role: role, Parts: []geminiPart{{Text: textContent}}})
+		result.Choices = append(result.Choices, choice)
+	}
+
+	if result.Choices[0].Content == "" && result.Choices[0].FinishReason != ai.FinishContentFilter {
+		return nil, fmt.Errorf("empty response from google")
 	}
 
+	result.Content = result.Choices[0].Content
+	result.FinishReason = result.Choices[0].FinishReason
+	result.Safety = result.Choices[0].Safety
+
+	return result, nil
+}
+
+type safetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked"`
+}
+
+type logprobCandidate struct {
+	Token          string  `json:"token"`
+	LogProbability float64 `json:"logProbability"`
+}
+
+func finishReason(reason string) ai.FinishReason {
+	switch reason {
+	case "", "FINISH_REASON_UNSPECIFIED":
+		return ""
+	case "STOP":
+		return ai.FinishStop
+	case "MAX_TOKENS":
+		return ai.FinishLength
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return ai.FinishContentFilter
+	case "MALFORMED_FUNCTION_CALL":
+		return ai.FinishToolCalls
+	}
+	return ai.FinishOther
+}
+
+func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	s := c.settings.Load()
+	req = s.ApplyDefaults(req)
+
+	streamChan := make(chan ai.StreamResponse, 10)
+
 	geminiReq := geminiRequest{
-		Contents: gContents,
-		GenerationConfig: genConfig{
-			Temperature:     req.Temperature,
-			MaxOutputTokens: req.MaxTokens,
-		},
+		Contents:         toGeminiContents(req.Messages),
+		GenerationConfig: toGenConfig(req),
 	}
 
-	jsonData, err := json.Marshal(geminiReq)
+	extras := s.RequestExtras(req, ai.ProviderGoogle)
+	jsonData, err := ai.MarshalWithExtras(geminiReq, extras)
 	if err != nil {
 		return nil, err
 	}
 
-	url := fmt.Sprintf(c.baseURL, c.model, c.apiKey)
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderGoogle)
+	url := fmt.Sprintf(baseURL, s.ModelFor(req), apiKey)
 	streamURL := strings.Replace(url, "generateContent", "streamGenerateContent", 1)
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", streamURL, bytes.NewBuffer(jsonData))
@@ -196,21 +316,28 @@ func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	extras.ApplyHeaders(httpReq.Header)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := s.HTTPClient.Do(httpReq)
 	if err != nil {
 		return nil, ai.ErrProviderDown
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("google stream status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("google stream status: %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	go func() {
 		defer resp.Body.Close()
 		defer close(streamChan)
 
+		if skipped := ai.UnsupportedParams(req, ai.ParamTemperature, ai.ParamTopP, ai.ParamTopK, ai.ParamStop,
+			ai.ParamSeed, ai.ParamPresencePenalty, ai.ParamFrequencyPenalty); len(skipped) > 0 {
+			streamChan <- ai.StreamResponse{Unsupported: skipped}
+		}
+
 		decoder := json.NewDecoder(resp.Body)
 		if token, err := decoder.Token(); err == nil {
 			if delim, ok := token.(json.Delim); ok && delim == '[' {
@@ -261,3 +388,54 @@ func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan
 
 	return streamChan, nil
 }
+
+func (c *Client) CountTokens(ctx context.Context, req ai.ChatRequest) (int, error) {
+	s := c.settings.Load()
+
+	countReq := struct {
+		Contents []geminiContent `json:"contents"`
+	}{
+		Contents: toGeminiContents(req.Messages),
+	}
+
+	jsonData, err := json.Marshal(countReq)
+	if err != nil {
+		return 0, err
+	}
+
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderGoogle)
+	url := fmt.Sprintf(baseURL, s.ModelFor(req), apiKey)
+	countURL := strings.Replace(url, "generateContent", "countTokens", 1)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", countURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.HTTPClient.Do(httpReq)
+	if err != nil {
+		return 0, ai.ErrProviderDown
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("google count tokens status: %d, body: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var apiResp struct {
+		TotalTokens int `json:"totalTokens"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return 0, err
+	}
+
+	return apiResp.TotalTokens, nil
+}
+
+func init() {
+	ai.Register(ai.ProviderGoogle, func(opts ...ai.Option) (ai.AIProvider, error) {
+		return NewClient("", opts...), nil
+	})
+}
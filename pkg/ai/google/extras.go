@@ -0,0 +1,14 @@
+package google
+
+import "github.com/ahmettasdemir/gopolyai/pkg/ai"
+
+type SafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// SafetySettings overrides Gemini's content filters, e.g.
+// {Category: "HARM_CATEGORY_HARASSMENT", Threshold: "BLOCK_ONLY_HIGH"}.
+func SafetySettings(settings ...SafetySetting) ai.Extras {
+	return ai.Extras{Body: map[string]interface{}{"safetySettings": settings}}
+}
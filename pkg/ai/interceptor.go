@@ -0,0 +1,248 @@
+package ai
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPExchange is a redacted record of one provider round trip. Secrets in
+// headers and query parameters are replaced with "[REDACTED]".
+type HTTPExchange struct {
+	Method          string
+	URL             string
+	RequestHeaders  http.Header
+	RequestBody     []byte
+	StatusCode      int
+	ResponseHeaders http.Header
+	ResponseBody    []byte
+	Duration        time.Duration
+	Err             error
+}
+
+// Interceptor observes and may adjust the raw HTTP traffic of a client.
+type Interceptor interface {
+	// InterceptRequest runs before the request is sent and may modify it,
+	// e.g. to sign it or add headers. It sees the live, unredacted request;
+	// body is a copy of its payload. To change the payload, replace
+	// req.Body: it is read again once all interceptors have run, and later
+	// interceptors see the new body. Returning an error aborts the call.
+	InterceptRequest(req *http.Request, body []byte) error
+
+	// InterceptResponse runs once the response body has been fully read and
+	// closed, so streaming responses are reported in full as well.
+	InterceptResponse(ex HTTPExchange)
+}
+
+// InterceptorFuncs adapts plain functions to Interceptor; nil funcs are
+// skipped.
+type InterceptorFuncs struct {
+	Request  func(req *http.Request, body []byte) error
+	Response func(ex HTTPExchange)
+}
+
+func (f InterceptorFuncs) InterceptRequest(req *http.Request, body []byte) error {
+	if f.Request == nil {
+		return nil
+	}
+	return f.Request(req, body)
+}
+
+func (f InterceptorFuncs) InterceptResponse(ex HTTPExchange) {
+	if f.Response != nil {
+		f.Response(ex)
+	}
+}
+
+// WithInterceptor installs interceptors on the client's transport. Apply it
+// after WithHTTPClient/WithTransport, which replace the transport.
+func WithInterceptor(interceptors ...Interceptor) Option {
+	return func(s *ClientSettings) {
+		hc := &http.Client{}
+		if s.HTTPClient != nil {
+			*hc = *s.HTTPClient
+		}
+		next := hc.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		hc.Transport = &interceptTransport{next: next, interceptors: interceptors}
+		s.HTTPClient = hc
+	}
+}
+
+// DumpInterceptor writes every redacted exchange to w, for debugging.
+func DumpInterceptor(w io.Writer) Interceptor {
+	var mu sync.Mutex
+	return InterceptorFuncs{Response: func(ex HTTPExchange) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		fmt.Fprintf(w, "--> %s %s\n", ex.Method, ex.URL)
+		for k, v := range ex.RequestHeaders {
+			fmt.Fprintf(w, "%s: %s\n", k, strings.Join(v, ", "))
+		}
+		fmt.Fprintf(w, "\n%s\n", ex.RequestBody)
+		if ex.Err != nil {
+			fmt.Fprintf(w, "<-- error after %v: %v\n\n", ex.Duration, ex.Err)
+			return
+		}
+		fmt.Fprintf(w, "<-- %d (%v)\n", ex.StatusCode, ex.Duration)
+		for k, v := range ex.ResponseHeaders {
+			fmt.Fprintf(w, "%s: %s\n", k, strings.Join(v, ", "))
+		}
+		fmt.Fprintf(w, "\n%s\n\n", ex.ResponseBody)
+	}}
+}
+
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"X-Goog-Api-Key",
+	"Api-Key",
+	"Cookie",
+	"Set-Cookie",
+}
+
+var sensitiveParams = []string{"key", "api_key", "access_token"}
+
+const redacted = "[REDACTED]"
+
+// RedactHeaders returns a copy of h with credential headers masked.
+func RedactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// RedactURL renders u with API keys in the query string and userinfo masked.
+func RedactURL(u *url.URL) string {
+	cp := *u
+	q := cp.Query()
+	changed := false
+	for _, p := range sensitiveParams {
+		if q.Has(p) {
+			q.Set(p, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		cp.RawQuery = q.Encode()
+	}
+	if cp.User != nil {
+		cp.User = url.User(redacted)
+	}
+	return cp.String()
+}
+
+type interceptTransport struct {
+	next         http.RoundTripper
+	interceptors []Interceptor
+}
+
+func (t *interceptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	body, err := rewind(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range t.interceptors {
+		if err := i.InterceptRequest(req, bytes.Clone(body)); err != nil {
+			return nil, err
+		}
+		if body, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+
+	ex := HTTPExchange{
+		Method:         req.Method,
+		URL:            RedactURL(req.URL),
+		RequestHeaders: RedactHeaders(req.Header),
+		RequestBody:    body,
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		ex.Duration = time.Since(start)
+		ex.Err = err
+		t.report(ex)
+		return nil, err
+	}
+
+	ex.StatusCode = resp.StatusCode
+	ex.ResponseHeaders = RedactHeaders(resp.Header)
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		onClose: func(data []byte, readErr error) {
+			ex.Duration = time.Since(start)
+			ex.ResponseBody = data
+			ex.Err = readErr
+			t.report(ex)
+		},
+	}
+	return resp, nil
+}
+
+// rewind reads the body of req and replaces it with a fresh reader over the
+// same bytes, so it can be both inspected and sent.
+func rewind(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	return body, nil
+}
+
+func (t *interceptTransport) report(ex HTTPExchange) {
+	for _, i := range t.interceptors {
+		i.InterceptResponse(ex)
+	}
+}
+
+// recordingBody tees everything the client reads and reports it once on
+// Close.
+type recordingBody struct {
+	io.ReadCloser
+	buf     bytes.Buffer
+	readErr error
+	once    sync.Once
+	onClose func(data []byte, readErr error)
+}
+
+func (r *recordingBody) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buf.Write(p[:n])
+	if err != nil && err != io.EOF {
+		r.readErr = err
+	}
+	return n, err
+}
+
+func (r *recordingBody) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(func() { r.onClose(r.buf.Bytes(), r.readErr) })
+	return err
+}
@@ -0,0 +1,124 @@
+package ai_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/google"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/openai"
+)
+
+func TestInterceptorSeesRedactedExchange(t *testing.T) {
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Signature")
+		w.Header().Set("X-Request-Id", "req-1")
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	var exchanges []ai.HTTPExchange
+	sign := ai.InterceptorFuncs{
+		Request: func(req *http.Request, body []byte) error {
+			req.Header.Set("X-Signature", fmt.Sprintf("sig-%d", len(body)))
+			return nil
+		},
+		Response: func(ex ai.HTTPExchange) { exchanges = append(exchanges, ex) },
+	}
+
+	client := openai.NewClient("sk-secret",
+		ai.WithBaseURL(server.URL),
+		ai.WithHTTPClient(server.Client()),
+		ai.WithInterceptor(sign),
+	)
+	if _, err := client.Generate(context.Background(), ai.ChatRequest{}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if !strings.HasPrefix(signature, "sig-") {
+		t.Errorf("request interceptor could not add headers, got %q", signature)
+	}
+	if len(exchanges) != 1 {
+		t.Fatalf("expected 1 exchange, got %d", len(exchanges))
+	}
+	ex := exchanges[0]
+	if got := ex.RequestHeaders.Get("Authorization"); got != "[REDACTED]" {
+		t.Errorf("authorization not redacted: %q", got)
+	}
+	if !bytes.Contains(ex.RequestBody, []byte(`"model"`)) {
+		t.Errorf("request body not captured: %s", ex.RequestBody)
+	}
+	if ex.StatusCode != 200 || ex.ResponseHeaders.Get("X-Request-Id") != "req-1" {
+		t.Errorf("response metadata not captured: %+v", ex)
+	}
+	if !bytes.Contains(ex.ResponseBody, []byte(`"ok"`)) {
+		t.Errorf("response body not captured: %s", ex.ResponseBody)
+	}
+}
+
+func TestInterceptorRedactsQueryKeyAndErrorBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"message": "bad model"}}`))
+	}))
+	defer server.Close()
+
+	var dump bytes.Buffer
+	client := google.NewClient("g-secret",
+		ai.WithBaseURL(server.URL+"/models/%s:generateContent?key=%s"),
+		ai.WithHTTPClient(server.Client()),
+		ai.WithInterceptor(ai.DumpInterceptor(&dump)),
+	)
+
+	_, err := client.Generate(context.Background(), ai.ChatRequest{Messages: []ai.ChatMessage{{Role: "user", Content: []ai.Content{{Type: "text", Text: "hi"}}}}})
+	if err == nil || !strings.Contains(err.Error(), "bad model") {
+		t.Errorf("error body not surfaced: %v", err)
+	}
+	if strings.Contains(dump.String(), "g-secret") {
+		t.Errorf("api key leaked into dump:\n%s", dump.String())
+	}
+	if !strings.Contains(dump.String(), "<-- 400") {
+		t.Errorf("status missing from dump:\n%s", dump.String())
+	}
+}
+
+func TestInterceptorCanReplaceBody(t *testing.T) {
+	var sent []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent, _ = io.ReadAll(r.Body)
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	var seen, recorded []byte
+	rewrite := ai.InterceptorFuncs{Request: func(req *http.Request, body []byte) error {
+		req.Body = io.NopCloser(bytes.NewReader(bytes.Replace(body, []byte(`"model"`), []byte(`"model_id"`), 1)))
+		return nil
+	}}
+	observe := ai.InterceptorFuncs{
+		Request:  func(req *http.Request, body []byte) error { seen = body; return nil },
+		Response: func(ex ai.HTTPExchange) { recorded = ex.RequestBody },
+	}
+
+	client := openai.NewClient("sk-secret",
+		ai.WithBaseURL(server.URL),
+		ai.WithHTTPClient(server.Client()),
+		ai.WithInterceptor(rewrite, observe),
+	)
+	if _, err := client.Generate(context.Background(), ai.ChatRequest{}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for name, got := range map[string][]byte{"sent": sent, "seen by next interceptor": seen, "recorded": recorded} {
+		if !bytes.Contains(got, []byte(`"model_id"`)) {
+			t.Errorf("%s body misses the rewrite: %s", name, got)
+		}
+	}
+}
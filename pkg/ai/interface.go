@@ -15,4 +15,78 @@ type StreamResponse struct {
 	Chunk string
 	Err   error
 	Usage *TokenUsage
+
+	// Unsupported is set on the first packet when the provider ignored
+	// some request parameters.
+	Unsupported []string
+}
+
+// Tokenizer counts the tokens of a request locally, without a network call.
+type Tokenizer interface {
+	CountTokens(req ChatRequest) (int, error)
+}
+
+// TokenCounter is an optional capability for providers that can count
+// prompt tokens remotely with the vendor's own tokenizer.
+type TokenCounter interface {
+	CountTokens(ctx context.Context, req ChatRequest) (int, error)
+}
+
+// DefaultModeler is implemented by clients that report the model they use
+// when a request leaves Model empty.
+type DefaultModeler interface {
+	DefaultModel() string
+}
+
+// ModelOrDefault returns model, or the default model of p when it is empty
+// and p reports one through DefaultModeler.
+func ModelOrDefault(p AIProvider, model string) string {
+	if model != "" {
+		return model
+	}
+	if d, ok := As[DefaultModeler](p); ok {
+		return d.DefaultModel()
+	}
+	return ""
+}
+
+// Unwrapper is implemented by middleware so optional capabilities of the
+// wrapped provider stay reachable through the pipeline.
+type Unwrapper interface {
+	Unwrap() AIProvider
+}
+
+// As walks the middleware chain starting at p and returns the first
+// provider implementing T.
+func As[T any](p AIProvider) (T, bool) {
+	for p != nil {
+		if t, ok := p.(T); ok {
+			return t, true
+		}
+		u, ok := p.(Unwrapper)
+		if !ok {
+			break
+		}
+		p = u.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// HealthChecker is an optional capability for providers that can be probed
+// without spending tokens on a prompt.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// Warmer is implemented by providers that can preload a model so the first
+// real request does not pay the load time.
+type Warmer interface {
+	Warmup(ctx context.Context) error
+}
+
+// HealthReporter exposes the last known health of a provider, typically as
+// tracked by a background prober.
+type HealthReporter interface {
+	Healthy() bool
 }
@@ -14,6 +14,10 @@ type LogEntry struct {
 	Operation string
 	Error     error
 
+	// ModelAlias is the logical name the caller asked for, such as "fast",
+	// when Model was resolved from an alias.
+	ModelAlias string
+
 	InputTokens  int
 	OutputTokens int
 	TotalTokens  int
@@ -22,6 +26,9 @@ type LogEntry struct {
 	RequestPayload  string
 	ResponsePayload string
 	TraceID         string
+
+	PromptName    string
+	PromptVersion string
 }
 
 type Logger interface {
@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// JSONLogger writes one JSON object per entry, e.g. to stderr for a log
+// shipper to pick up.
+type JSONLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{enc: json.NewEncoder(w)}
+}
+
+func (j *JSONLogger) Log(ctx context.Context, entry LogEntry) {
+	type record struct {
+		LogEntry
+		Error string `json:",omitempty"`
+	}
+	rec := record{LogEntry: entry}
+	if entry.Error != nil {
+		rec.Error = entry.Error.Error()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.enc.Encode(rec)
+}
@@ -45,6 +45,10 @@ func (cb *CircuitBreaker) Name() string {
 	return fmt.Sprintf("%s (Protected)", cb.provider.Name())
 }
 
+func (cb *CircuitBreaker) Unwrap() ai.AIProvider {
+	return cb.provider
+}
+
 func (cb *CircuitBreaker) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
 	cb.mu.Lock()
 
@@ -101,3 +105,33 @@ func (cb *CircuitBreaker) GenerateStream(ctx context.Context, req ai.ChatRequest
 	// For v1.4.0, Stream passes through. In v2.0, Full Stream Protection will be added.
 	return cb.provider.GenerateStream(ctx, req)
 }
+
+// ObserveHealth lets a HealthProber open the circuit before real traffic
+// fails. A passing probe only moves an open circuit to half-open; the next
+// real request decides whether it closes.
+func (cb *CircuitBreaker) ObserveHealth(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if err != nil {
+		if cb.state != StateOpen {
+			fmt.Printf("🔥 [Circuit Breaker] Health probe failed (%v). CIRCUIT OPENING.\n", err)
+		}
+		cb.state = StateOpen
+		cb.failures = cb.failureThreshold
+		cb.lastFailureTime = time.Now()
+		return
+	}
+
+	if cb.state == StateOpen {
+		fmt.Println("⚡ [Circuit Breaker] Health probe passed, testing system (Half-Open)...")
+		cb.state = StateHalfOpen
+	}
+}
+
+// State returns the current circuit state.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
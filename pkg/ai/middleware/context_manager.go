@@ -0,0 +1,342 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/tokenizer"
+)
+
+// DefaultOutputReserve is kept free for the reply when the request does not
+// set MaxTokens.
+const DefaultOutputReserve = 1024
+
+// TrimStrategy shortens a request until it fits into budget tokens as
+// measured by tok.
+type TrimStrategy interface {
+	Trim(ctx context.Context, req ai.ChatRequest, budget int, tok ai.Tokenizer) (ai.ChatRequest, error)
+}
+
+// ContextManager trims requests to the context window of their model. The
+// window comes from the provider's capability catalog, reached with
+// ai.As[ai.CapabilityProvider]; SetContextWindows overrides it.
+type ContextManager struct {
+	provider ai.AIProvider
+	strategy TrimStrategy
+	windows  map[string]int
+
+	// discovered caches windows reported by an ai.CapabilityProvider,
+	// keyed by the resolved model name.
+	discovered sync.Map
+}
+
+func NewContextManager(p ai.AIProvider, strategy TrimStrategy) *ContextManager {
+	if strategy == nil {
+		strategy = DropOldest{}
+	}
+	return &ContextManager{
+		provider: p,
+		strategy: strategy,
+	}
+}
+
+// SetContextWindows sets windows by model prefix that take precedence over
+// the ones the provider reports.
+func (cm *ContextManager) SetContextWindows(w map[string]int) {
+	cm.windows = w
+}
+
+func (cm *ContextManager) Configure(cfg ai.Config) error {
+	return cm.provider.Configure(cfg)
+}
+
+func (cm *ContextManager) Name() string {
+	return cm.provider.Name()
+}
+
+func (cm *ContextManager) Unwrap() ai.AIProvider {
+	return cm.provider
+}
+
+func (cm *ContextManager) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	fitted, err := cm.fit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := cm.provider.Generate(ctx, fitted)
+	if err == nil || !IsContextError(err) {
+		return resp, err
+	}
+
+	retryReq, trimErr := cm.shrink(ctx, fitted)
+	if trimErr != nil {
+		return nil, fmt.Errorf("%w: %v", ai.ErrContextExceeded, err)
+	}
+	return cm.provider.Generate(ctx, retryReq)
+}
+
+func (cm *ContextManager) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	fitted, err := cm.fit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := cm.provider.GenerateStream(ctx, fitted)
+	if err == nil || !IsContextError(err) {
+		return stream, err
+	}
+
+	retryReq, trimErr := cm.shrink(ctx, fitted)
+	if trimErr != nil {
+		return nil, fmt.Errorf("%w: %v", ai.ErrContextExceeded, err)
+	}
+	return cm.provider.GenerateStream(ctx, retryReq)
+}
+
+// ContextWindow returns the context window for model, matching the longest
+// prefix set with SetContextWindows, or else the one the provider reports.
+// An empty model means the provider's default. It returns 0 when unknown.
+func (cm *ContextManager) ContextWindow(ctx context.Context, model string) int {
+	model = ai.ModelOrDefault(cm.provider, model)
+	if w := lookupByPrefix(cm.windows, model); w > 0 {
+		return w
+	}
+	if w, ok := cm.discovered.Load(model); ok {
+		return w.(int)
+	}
+
+	cp, ok := ai.As[ai.CapabilityProvider](cm.provider)
+	if !ok {
+		return 0
+	}
+	caps, err := cp.Capabilities(ctx, model)
+	if err != nil {
+		return 0
+	}
+	// Without a name the default may change with Configure, so only
+	// resolved models are cached.
+	if model != "" {
+		cm.discovered.Store(model, caps.ContextWindow)
+	}
+	return caps.ContextWindow
+}
+
+func (cm *ContextManager) budget(ctx context.Context, req ai.ChatRequest) int {
+	window := cm.ContextWindow(ctx, req.Model)
+	if window == 0 {
+		return 0
+	}
+	reserve := req.MaxTokens
+	if reserve <= 0 {
+		reserve = DefaultOutputReserve
+	}
+	return window - reserve
+}
+
+func (cm *ContextManager) fit(ctx context.Context, req ai.ChatRequest) (ai.ChatRequest, error) {
+	budget := cm.budget(ctx, req)
+	if budget <= 0 {
+		return req, nil
+	}
+	return cm.trim(ctx, req, budget)
+}
+
+// shrink is used after the provider rejected the request anyway: our
+// estimate was too optimistic, so aim a quarter lower than the current size.
+func (cm *ContextManager) shrink(ctx context.Context, req ai.ChatRequest) (ai.ChatRequest, error) {
+	tok := cm.tokenizer(req)
+	current, err := tok.CountTokens(req)
+	if err != nil {
+		return req, err
+	}
+	return cm.trim(ctx, req, current*3/4)
+}
+
+// tokenizer counts for the model req is served by.
+func (cm *ContextManager) tokenizer(req ai.ChatRequest) ai.Tokenizer {
+	return tokenizer.ForModel(ai.ModelOrDefault(cm.provider, req.Model))
+}
+
+func (cm *ContextManager) trim(ctx context.Context, req ai.ChatRequest, budget int) (ai.ChatRequest, error) {
+	tok := cm.tokenizer(req)
+	count, err := tok.CountTokens(req)
+	if err != nil {
+		return req, err
+	}
+	if count <= budget {
+		return req, nil
+	}
+
+	trimmed, err := cm.strategy.Trim(ctx, req, budget, tok)
+	if err != nil {
+		return req, err
+	}
+
+	count, err = tok.CountTokens(trimmed)
+	if err != nil {
+		return req, err
+	}
+	if count > budget {
+		return req, fmt.Errorf("%w: %d tokens after trimming, budget %d", ai.ErrContextExceeded, count, budget)
+	}
+	return trimmed, nil
+}
+
+var contextErrorMarkers = []string{
+	"context_length_exceeded",
+	"maximum context length",
+	"context window",
+	"prompt is too long",
+	"too many tokens",
+	"exceeds the maximum number of tokens",
+}
+
+// IsContextError reports whether err means the prompt did not fit into the
+// model's context window.
+func IsContextError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ai.ErrContextExceeded) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range contextErrorMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupByPrefix[V any](table map[string]V, model string) V {
+	if v, ok := table[model]; ok {
+		return v
+	}
+
+	var best V
+	var maxLen int
+	for key, v := range table {
+		if strings.HasPrefix(model, key) && len(key) > maxLen {
+			maxLen = len(key)
+			best = v
+		}
+	}
+	return best
+}
+
+// splitSystem separates leading system messages, which every strategy keeps,
+// from the conversation turns. System messages later in the conversation
+// stay in place among the turns.
+func splitSystem(messages []ai.ChatMessage) (system, turns []ai.ChatMessage) {
+	i := 0
+	for i < len(messages) && messages[i].Role == "system" {
+		i++
+	}
+	return messages[:i:i], messages[i:]
+}
+
+func join(system, turns []ai.ChatMessage) []ai.ChatMessage {
+	out := make([]ai.ChatMessage, 0, len(system)+len(turns))
+	out = append(out, system...)
+	return append(out, turns...)
+}
+
+// DropOldest removes the oldest turns after the leading system messages
+// until the request fits. The latest turn is never dropped.
+type DropOldest struct{}
+
+func (DropOldest) Trim(ctx context.Context, req ai.ChatRequest, budget int, tok ai.Tokenizer) (ai.ChatRequest, error) {
+	system, turns := splitSystem(req.Messages)
+
+	for len(turns) > 1 {
+		req.Messages = join(system, turns)
+		count, err := tok.CountTokens(req)
+		if err != nil {
+			return req, err
+		}
+		if count <= budget {
+			return req, nil
+		}
+		turns = turns[1:]
+	}
+
+	req.Messages = join(system, turns)
+	return req, nil
+}
+
+// KeepLastN keeps the system prompt plus the last N turns, then drops
+// further turns if that is still too large.
+type KeepLastN struct {
+	N int
+}
+
+func (k KeepLastN) Trim(ctx context.Context, req ai.ChatRequest, budget int, tok ai.Tokenizer) (ai.ChatRequest, error) {
+	system, turns := splitSystem(req.Messages)
+	if k.N > 0 && len(turns) > k.N {
+		turns = turns[len(turns)-k.N:]
+	}
+	req.Messages = join(system, turns)
+	return DropOldest{}.Trim(ctx, req, budget, tok)
+}
+
+// SummarizeMiddle replaces everything between the system prompt and the last
+// KeepLast turns with a summary written by Summarizer, typically a cheaper
+// model.
+type SummarizeMiddle struct {
+	Summarizer ai.AIProvider
+	Model      string
+	KeepLast   int
+}
+
+func (s SummarizeMiddle) Trim(ctx context.Context, req ai.ChatRequest, budget int, tok ai.Tokenizer) (ai.ChatRequest, error) {
+	keep := s.KeepLast
+	if keep <= 0 {
+		keep = 4
+	}
+
+	system, turns := splitSystem(req.Messages)
+	if len(turns) <= keep || s.Summarizer == nil {
+		return DropOldest{}.Trim(ctx, req, budget, tok)
+	}
+
+	middle, recent := turns[:len(turns)-keep], turns[len(turns)-keep:]
+
+	var transcript strings.Builder
+	for _, msg := range middle {
+		transcript.WriteString(msg.Role)
+		transcript.WriteString(": ")
+		for _, part := range msg.Content {
+			if part.Type == "text" {
+				transcript.WriteString(part.Text)
+			}
+		}
+		transcript.WriteString("\n")
+	}
+
+	summaryReq := ai.ChatRequest{
+		Model: s.Model,
+		Messages: []ai.ChatMessage{
+			{Role: "system", Content: []ai.Content{{Type: "text", Text: "Summarize the following conversation concisely. Keep names, facts, decisions and open questions."}}},
+			{Role: "user", Content: []ai.Content{{Type: "text", Text: transcript.String()}}},
+		},
+	}
+
+	resp, err := s.Summarizer.Generate(ctx, summaryReq)
+	if err != nil {
+		return DropOldest{}.Trim(ctx, req, budget, tok)
+	}
+
+	summary := ai.ChatMessage{
+		Role:    "system",
+		Content: []ai.Content{{Type: "text", Text: "Summary of the earlier conversation: " + resp.Content}},
+	}
+
+	req.Messages = join(append(system, summary), recent)
+	return DropOldest{}.Trim(ctx, req, budget, tok)
+}
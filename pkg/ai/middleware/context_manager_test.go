@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+type contextLimitedProvider struct {
+	MaxMessages int
+	Calls       int
+	LastReq     ai.ChatRequest
+}
+
+func (m *contextLimitedProvider) Configure(cfg ai.Config) error { return nil }
+func (m *contextLimitedProvider) Name() string                  { return "ContextLimited" }
+func (m *contextLimitedProvider) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	m.Calls++
+	m.LastReq = req
+	if m.MaxMessages > 0 && len(req.Messages) > m.MaxMessages {
+		return nil, errors.New("openai status: 400, body: This model's maximum context length is 10 tokens")
+	}
+	return &ai.ChatResponse{Content: "ok"}, nil
+}
+func (m *contextLimitedProvider) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	return nil, nil
+}
+
+func conversation(system string, turns int, words int) []ai.ChatMessage {
+	msgs := []ai.ChatMessage{{Role: "system", Content: []ai.Content{{Type: "text", Text: system}}}}
+	for i := 0; i < turns; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		text := fmt.Sprintf("turn-%d %s", i, strings.Repeat("word ", words))
+		msgs = append(msgs, ai.ChatMessage{Role: role, Content: []ai.Content{{Type: "text", Text: text}}})
+	}
+	return msgs
+}
+
+func TestContextManager_DropOldest(t *testing.T) {
+	mock := &contextLimitedProvider{}
+	cm := NewContextManager(mock, DropOldest{})
+	cm.SetContextWindows(map[string]int{"tiny": 1300})
+
+	req := ai.ChatRequest{Model: "tiny-model", Messages: conversation("be nice", 10, 50)}
+	if _, err := cm.Generate(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := mock.LastReq.Messages
+	if got[0].Role != "system" {
+		t.Error("system prompt was dropped")
+	}
+	if len(got) >= len(req.Messages) {
+		t.Errorf("expected history to be trimmed, got %d messages", len(got))
+	}
+	last := got[len(got)-1].Content[0].Text
+	if !strings.HasPrefix(last, "turn-9") {
+		t.Errorf("latest turn was dropped, last message: %q", last)
+	}
+}
+
+func TestContextManager_KeepLastN(t *testing.T) {
+	mock := &contextLimitedProvider{}
+	cm := NewContextManager(mock, KeepLastN{N: 2})
+	cm.SetContextWindows(map[string]int{"tiny": 1300})
+
+	req := ai.ChatRequest{Model: "tiny", Messages: conversation("be nice", 10, 50)}
+	if _, err := cm.Generate(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mock.LastReq.Messages) != 3 {
+		t.Errorf("expected system + 2 turns, got %d messages", len(mock.LastReq.Messages))
+	}
+}
+
+func TestContextManager_SummarizeMiddle(t *testing.T) {
+	mock := &contextLimitedProvider{}
+	summarizer := &MockProvider{}
+	cm := NewContextManager(mock, SummarizeMiddle{Summarizer: summarizer, KeepLast: 2})
+	cm.SetContextWindows(map[string]int{"tiny": 1300})
+
+	req := ai.ChatRequest{Model: "tiny", Messages: conversation("be nice", 10, 50)}
+	if _, err := cm.Generate(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summarizer.CallCount != 1 {
+		t.Errorf("expected summarizer to be called once, got %d", summarizer.CallCount)
+	}
+	got := mock.LastReq.Messages
+	if len(got) != 4 || !strings.Contains(got[1].Content[0].Text, "Summary") {
+		t.Errorf("expected system, summary and 2 turns, got %+v", got)
+	}
+}
+
+func TestContextManager_RetryOnProviderRejection(t *testing.T) {
+	mock := &contextLimitedProvider{MaxMessages: 8}
+	cm := NewContextManager(mock, DropOldest{})
+
+	req := ai.ChatRequest{Model: "unknown-model", Messages: conversation("be nice", 10, 5)}
+	if _, err := cm.Generate(context.Background(), req); err != nil {
+		t.Fatalf("expected trimmed retry to succeed, got %v", err)
+	}
+	if mock.Calls != 2 {
+		t.Errorf("expected exactly one retry, got %d calls", mock.Calls)
+	}
+}
+
+func TestContextManager_Exceeded(t *testing.T) {
+	mock := &contextLimitedProvider{}
+	cm := NewContextManager(mock, DropOldest{})
+	cm.SetContextWindows(map[string]int{"tiny": 1100})
+
+	req := ai.ChatRequest{Model: "tiny", Messages: conversation("be nice", 1, 500)}
+	_, err := cm.Generate(context.Background(), req)
+	if !errors.Is(err, ai.ErrContextExceeded) {
+		t.Fatalf("expected ErrContextExceeded, got %v", err)
+	}
+	if mock.Calls != 0 {
+		t.Error("provider should not be called when the request cannot fit")
+	}
+}
+
+func TestContextManager_KeepsLaterSystemMessagesInPlace(t *testing.T) {
+	msgs := conversation("be nice", 4, 1)
+	mid := ai.ChatMessage{Role: "system", Content: []ai.Content{{Type: "text", Text: "now answer in French"}}}
+	msgs = append(msgs[:3], append([]ai.ChatMessage{mid}, msgs[3:]...)...)
+
+	system, turns := splitSystem(msgs)
+	if len(system) != 1 || len(turns) != 5 || turns[2].Role != "system" {
+		t.Fatalf("system = %+v, turns = %+v", system, turns)
+	}
+
+	// SummarizeMiddle appends to the leading messages; that must not
+	// clobber the caller's slice.
+	_ = append(system, ai.ChatMessage{Role: "system"})
+	if msgs[1].Role != "user" {
+		t.Errorf("splitSystem aliased the input: %+v", msgs[1])
+	}
+}
+
+// defaultModelCaps serves the model configured last when a request names
+// none, and reports a window per model.
+type defaultModelCaps struct {
+	contextLimitedProvider
+	model   string
+	windows map[string]int
+	asked   []string
+}
+
+func (p *defaultModelCaps) Configure(cfg ai.Config) error {
+	p.model = cfg.ModelName
+	return nil
+}
+func (p *defaultModelCaps) DefaultModel() string { return p.model }
+func (p *defaultModelCaps) Capabilities(ctx context.Context, model string) (ai.Capabilities, error) {
+	p.asked = append(p.asked, model)
+	return ai.Capabilities{Model: model, ContextWindow: p.windows[model]}, nil
+}
+func (p *defaultModelCaps) ListModels(ctx context.Context) ([]ai.ModelInfo, error) { return nil, nil }
+
+func TestContextManager_ResolvesDefaultModel(t *testing.T) {
+	p := &defaultModelCaps{model: "small", windows: map[string]int{"small": 1100, "large": 100_000}}
+	cm := NewContextManager(p, DropOldest{})
+	ctx := context.Background()
+
+	if w := cm.ContextWindow(ctx, ""); w != 1100 {
+		t.Errorf("default window = %d", w)
+	}
+	cm.Configure(ai.Config{ModelName: "large"})
+	if w := cm.ContextWindow(ctx, ""); w != 100_000 {
+		t.Errorf("window after Configure = %d", w)
+	}
+	cm.ContextWindow(ctx, "large")
+	if strings.Join(p.asked, ",") != "small,large" {
+		t.Errorf("capabilities asked for %v, want each resolved model once", p.asked)
+	}
+}
@@ -15,6 +15,7 @@ type ModelPrice struct {
 var DefaultPricing = map[string]ModelPrice{
 	// OpenAI
 	"gpt-4o":        {InputPrice: 5.00, OutputPrice: 15.00},
+	"gpt-4o-mini":   {InputPrice: 0.15, OutputPrice: 0.60},
 	"gpt-4-turbo":   {InputPrice: 10.00, OutputPrice: 30.00},
 	"gpt-3.5-turbo": {InputPrice: 0.50, OutputPrice: 1.50},
 
@@ -55,6 +56,10 @@ func (ce *CostEstimator) Name() string {
 	return ce.provider.Name()
 }
 
+func (ce *CostEstimator) Unwrap() ai.AIProvider {
+	return ce.provider
+}
+
 func (ce *CostEstimator) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
 	resp, err := ce.provider.Generate(ctx, req)
 	if err != nil {
@@ -62,8 +67,14 @@ func (ce *CostEstimator) Generate(ctx context.Context, req ai.ChatRequest) (*ai.
 	}
 
 	modelName := req.Model
+	if modelName == "" {
+		modelName = resp.Model
+	}
+	modelName = ai.ModelOrDefault(ce.provider, modelName)
 	price, found := ce.findPrice(modelName)
 
+	resp.Usage.Model = modelName
+	resp.Usage.ModelAlias = ai.ModelAliasFrom(ctx)
 	if found && (resp.Usage.InputTokens > 0 || resp.Usage.OutputTokens > 0) {
 		inputCost := (float64(resp.Usage.InputTokens) / 1_000_000) * price.InputPrice
 		outputCost := (float64(resp.Usage.OutputTokens) / 1_000_000) * price.OutputPrice
@@ -83,13 +94,18 @@ func (ce *CostEstimator) GenerateStream(ctx context.Context, req ai.ChatRequest)
 	}
 
 	proxyChan := make(chan ai.StreamResponse, 10)
-	modelName := req.Model
+	modelName := ai.ModelOrDefault(ce.provider, req.Model)
+	alias := ai.ModelAliasFrom(ctx)
 	price, found := ce.findPrice(modelName)
 
 	go func() {
 		defer close(proxyChan)
 
 		for packet := range originalChan {
+			if packet.Usage != nil {
+				packet.Usage.Model = modelName
+				packet.Usage.ModelAlias = alias
+			}
 			if packet.Usage != nil && found {
 				inputCost := (float64(packet.Usage.InputTokens) / 1_000_000) * price.InputPrice
 				outputCost := (float64(packet.Usage.OutputTokens) / 1_000_000) * price.OutputPrice
@@ -105,14 +121,21 @@ func (ce *CostEstimator) GenerateStream(ctx context.Context, req ai.ChatRequest)
 }
 
 func (ce *CostEstimator) findPrice(model string) (ModelPrice, bool) {
-	if p, ok := ce.pricing[model]; ok {
+	return LookupPrice(ce.pricing, model)
+}
+
+// LookupPrice finds the price of model in pricing, falling back to the
+// longest key that is a prefix of it, so dated snapshots such as
+// "gpt-4o-2024-08-06" use the "gpt-4o" price.
+func LookupPrice(pricing map[string]ModelPrice, model string) (ModelPrice, bool) {
+	if p, ok := pricing[model]; ok {
 		return p, true
 	}
 
 	var bestMatchKey string
 	var maxLen int
 
-	for key := range ce.pricing {
+	for key := range pricing {
 		if strings.HasPrefix(model, key) {
 			if len(key) > maxLen {
 				maxLen = len(key)
@@ -122,7 +145,7 @@ func (ce *CostEstimator) findPrice(model string) (ModelPrice, bool) {
 	}
 
 	if bestMatchKey != "" {
-		return ce.pricing[bestMatchKey], true
+		return pricing[bestMatchKey], true
 	}
 
 	return ModelPrice{}, false
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// defaultModelProvider reports a default model and echoes respModel back.
+type defaultModelProvider struct {
+	respModel string
+}
+
+func (p *defaultModelProvider) Configure(cfg ai.Config) error { return nil }
+func (p *defaultModelProvider) Name() string                  { return "DefaultModel" }
+func (p *defaultModelProvider) DefaultModel() string          { return "gpt-4o-mini" }
+
+func (p *defaultModelProvider) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	return &ai.ChatResponse{Model: p.respModel, Usage: ai.TokenUsage{InputTokens: 1_000_000}}, nil
+}
+
+func (p *defaultModelProvider) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	ch := make(chan ai.StreamResponse, 1)
+	ch <- ai.StreamResponse{Usage: &ai.TokenUsage{InputTokens: 1_000_000}}
+	close(ch)
+	return ch, nil
+}
+
+func TestCostEstimatorPricesRequestsWithoutModel(t *testing.T) {
+	ctx := context.Background()
+
+	// The model the provider reports wins over its default.
+	resp, _ := NewCostEstimator(&defaultModelProvider{respModel: "gpt-4o-2024-08-06"}).Generate(ctx, ai.ChatRequest{})
+	if resp.Usage.CostUSD != 5.00 {
+		t.Errorf("priced by response model: cost = %v", resp.Usage.CostUSD)
+	}
+
+	resp, _ = NewCostEstimator(&defaultModelProvider{}).Generate(ctx, ai.ChatRequest{})
+	if resp.Usage.CostUSD != 0.15 {
+		t.Errorf("priced by default model: cost = %v", resp.Usage.CostUSD)
+	}
+
+	stream, _ := NewCostEstimator(&defaultModelProvider{}).GenerateStream(ctx, ai.ChatRequest{})
+	for packet := range stream {
+		if packet.Usage.CostUSD != 0.15 {
+			t.Errorf("stream: cost = %v", packet.Usage.CostUSD)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// HealthObserver is implemented by middleware that reacts to probe results,
+// such as CircuitBreaker.
+type HealthObserver interface {
+	ObserveHealth(err error)
+}
+
+// HealthProber periodically probes the wrapped provider through its
+// ai.HealthChecker and reports every result to the HealthObservers in the
+// chain below it, so traffic moves away before users hit errors. Providers
+// without a HealthChecker are always reported healthy.
+type HealthProber struct {
+	provider ai.AIProvider
+	interval time.Duration
+	timeout  time.Duration
+
+	healthy atomic.Bool
+
+	mu        sync.Mutex
+	lastErr   error
+	lastCheck time.Time
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// Defaults for NewHealthProber when interval or timeout is not positive.
+const (
+	DefaultProbeInterval = 30 * time.Second
+	DefaultProbeTimeout  = 5 * time.Second
+)
+
+// NewHealthProber probes p every interval, giving each probe at most
+// timeout (capped at interval).
+func NewHealthProber(p ai.AIProvider, interval, timeout time.Duration) *HealthProber {
+	if interval <= 0 {
+		interval = DefaultProbeInterval
+	}
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+	if timeout > interval {
+		timeout = interval
+	}
+	hp := &HealthProber{provider: p, interval: interval, timeout: timeout}
+	hp.healthy.Store(true)
+	return hp
+}
+
+func (hp *HealthProber) Configure(cfg ai.Config) error {
+	return hp.provider.Configure(cfg)
+}
+
+func (hp *HealthProber) Name() string {
+	return hp.provider.Name()
+}
+
+func (hp *HealthProber) Unwrap() ai.AIProvider {
+	return hp.provider
+}
+
+func (hp *HealthProber) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	return hp.provider.Generate(ctx, req)
+}
+
+func (hp *HealthProber) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	return hp.provider.GenerateStream(ctx, req)
+}
+
+// Healthy reports the result of the most recent probe.
+func (hp *HealthProber) Healthy() bool {
+	return hp.healthy.Load()
+}
+
+// LastResult returns the error and time of the most recent probe.
+func (hp *HealthProber) LastResult() (time.Time, error) {
+	hp.mu.Lock()
+	defer hp.mu.Unlock()
+	return hp.lastCheck, hp.lastErr
+}
+
+// Start warms the provider up if it is an ai.Warmer and then probes every
+// interval until Stop is called or ctx is done.
+func (hp *HealthProber) Start(ctx context.Context) {
+	hp.mu.Lock()
+	if hp.cancel != nil {
+		hp.mu.Unlock()
+		return
+	}
+	ctx, hp.cancel = context.WithCancel(ctx)
+	hp.done = make(chan struct{})
+	hp.mu.Unlock()
+
+	go func() {
+		defer close(hp.done)
+
+		if w, ok := ai.As[ai.Warmer](hp.provider); ok {
+			wctx, cancel := context.WithTimeout(ctx, hp.timeout)
+			w.Warmup(wctx)
+			cancel()
+		}
+
+		ticker := time.NewTicker(hp.interval)
+		defer ticker.Stop()
+		for {
+			hp.Probe(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop ends the probe loop and waits for it to exit.
+func (hp *HealthProber) Stop() {
+	hp.mu.Lock()
+	cancel, done := hp.cancel, hp.done
+	hp.cancel = nil
+	hp.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Probe runs a single health check and propagates the result.
+func (hp *HealthProber) Probe(ctx context.Context) error {
+	checker, ok := ai.As[ai.HealthChecker](hp.provider)
+	if !ok {
+		return nil
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, hp.timeout)
+	err := checker.HealthCheck(probeCtx)
+	cancel()
+
+	// A probe cut short by Stop says nothing about the provider.
+	if ctx.Err() != nil {
+		return err
+	}
+
+	hp.mu.Lock()
+	hp.lastErr = err
+	hp.lastCheck = time.Now()
+	hp.mu.Unlock()
+	hp.healthy.Store(err == nil)
+
+	var p ai.AIProvider = hp.provider
+	for p != nil {
+		if o, ok := p.(HealthObserver); ok {
+			o.ObserveHealth(err)
+		}
+		u, ok := p.(ai.Unwrapper)
+		if !ok {
+			break
+		}
+		p = u.Unwrap()
+	}
+	return err
+}
+
+// Close stops the probe loop and closes the wrapped provider if it holds
+// resources; it lets owners such as ai.Router release probers without
+// knowing their type.
+func (hp *HealthProber) Close() error {
+	hp.Stop()
+	if c, ok := ai.As[io.Closer](hp.provider); ok {
+		return c.Close()
+	}
+	return nil
+}
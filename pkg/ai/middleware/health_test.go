@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/mock"
+)
+
+type probedProvider struct {
+	MockProvider
+	down    atomic.Bool
+	warmed  atomic.Bool
+	checked atomic.Int32
+}
+
+func (p *probedProvider) HealthCheck(ctx context.Context) error {
+	p.checked.Add(1)
+	if p.down.Load() {
+		return ai.ErrProviderDown
+	}
+	return nil
+}
+
+func (p *probedProvider) Warmup(ctx context.Context) error {
+	p.warmed.Store(true)
+	return nil
+}
+
+func TestHealthProberOpensCircuitAndReroutes(t *testing.T) {
+	primary := &probedProvider{}
+	breaker := NewCircuitBreaker(primary, 5, time.Minute)
+	prober := NewHealthProber(breaker, time.Hour, time.Second)
+	fallback := ai.NewFallbackClient(prober, mock.NewClient("secondary", false))
+
+	primary.down.Store(true)
+	if err := prober.Probe(context.Background()); !errors.Is(err, ai.ErrProviderDown) {
+		t.Fatalf("expected probe failure, got %v", err)
+	}
+	if prober.Healthy() || breaker.State() != StateOpen {
+		t.Fatalf("failed probe should mark unhealthy and open the circuit (state %v)", breaker.State())
+	}
+
+	resp, err := fallback.Generate(context.Background(), ai.ChatRequest{})
+	if err != nil {
+		t.Fatalf("fallback failed: %v", err)
+	}
+	if primary.CallCount != 0 {
+		t.Errorf("unhealthy primary received %d calls", primary.CallCount)
+	}
+	if resp.Content == "" {
+		t.Error("expected secondary response")
+	}
+
+	primary.down.Store(false)
+	prober.Probe(context.Background())
+	if !prober.Healthy() || breaker.State() != StateHalfOpen {
+		t.Errorf("passing probe should move circuit to half-open, got %v", breaker.State())
+	}
+}
+
+func TestHealthProberStartWarmsUpAndStops(t *testing.T) {
+	p := &probedProvider{}
+	prober := NewHealthProber(p, 10*time.Millisecond, 0)
+
+	prober.Start(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	prober.Stop()
+
+	if !p.warmed.Load() {
+		t.Error("Start should warm up an ai.Warmer")
+	}
+	if p.checked.Load() < 2 {
+		t.Errorf("expected repeated probes, got %d", p.checked.Load())
+	}
+
+	after := p.checked.Load()
+	time.Sleep(30 * time.Millisecond)
+	if p.checked.Load() != after {
+		t.Error("probing continued after Stop")
+	}
+}
+
+func TestHealthProberDefaultsNonPositiveInterval(t *testing.T) {
+	prober := NewHealthProber(&probedProvider{}, 0, 0)
+	if prober.interval != DefaultProbeInterval || prober.timeout != DefaultProbeTimeout {
+		t.Errorf("got interval %v, timeout %v", prober.interval, prober.timeout)
+	}
+	prober.Start(context.Background()) // must not panic
+	prober.Stop()
+}
@@ -3,6 +3,8 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"io"
+	"sync"
 	"time"
 
 	"github.com/ahmettasdemir/gopolyai/pkg/ai"
@@ -13,6 +15,8 @@ type LoggingMiddleware struct {
 	next   ai.AIProvider
 	logger logger.Logger
 	config logger.Config
+
+	pending sync.WaitGroup // log writes still in flight
 }
 
 func NewLoggingMiddleware(next ai.AIProvider, l logger.Logger, cfg logger.Config) *LoggingMiddleware {
@@ -34,6 +38,21 @@ func (l *LoggingMiddleware) Name() string {
 	return l.next.Name()
 }
 
+func (l *LoggingMiddleware) Unwrap() ai.AIProvider {
+	return l.next
+}
+
+// Close waits for pending log writes, including those of streams still
+// being read, then closes the wrapped provider if it holds resources. Call
+// it before exiting so no entry is lost.
+func (l *LoggingMiddleware) Close() error {
+	l.pending.Wait()
+	if c, ok := ai.As[io.Closer](l.next); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 func (l *LoggingMiddleware) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
 	start := time.Now()
 
@@ -61,7 +80,9 @@ func (l *LoggingMiddleware) Generate(ctx context.Context, req ai.ChatRequest) (*
 		}
 	}
 
+	l.pending.Add(1)
 	go func(u ai.TokenUsage, resP string, reqP string, finalErr error, dur time.Duration) {
+		defer l.pending.Done()
 		if l.config.LogErrorsOnly && finalErr == nil {
 			return
 		}
@@ -70,7 +91,8 @@ func (l *LoggingMiddleware) Generate(ctx context.Context, req ai.ChatRequest) (*
 			Timestamp:       start.Add(dur),
 			Duration:        dur,
 			Provider:        l.next.Name(),
-			Model:           req.Model,
+			Model:           ai.ModelOrDefault(l.next, req.Model),
+			ModelAlias:      ai.ModelAliasFrom(ctx),
 			Operation:       "Generate",
 			Error:           finalErr,
 			TraceID:         GetTraceID(ctx),
@@ -81,6 +103,10 @@ func (l *LoggingMiddleware) Generate(ctx context.Context, req ai.ChatRequest) (*
 			RequestPayload:  reqP,
 			ResponsePayload: resP,
 		}
+		if req.Prompt != nil {
+			entry.PromptName = req.Prompt.Name
+			entry.PromptVersion = req.Prompt.Version
+		}
 
 		l.logger.Log(context.Background(), entry)
 	}(usage, responseContent, requestContent, err, duration)
@@ -94,14 +120,21 @@ func (l *LoggingMiddleware) GenerateStream(ctx context.Context, req ai.ChatReque
 	originalChan, err := l.next.GenerateStream(ctx, req)
 	if err != nil {
 		traceID := GetTraceID(ctx)
-		go l.logStreamSummary(start, req.Model, nil, "", err, traceID)
+		l.pending.Add(1)
+		go func() {
+			defer l.pending.Done()
+			l.logStreamSummary(start, req, nil, "", err, traceID, ai.ModelAliasFrom(ctx))
+		}()
 		return nil, err
 	}
 
 	proxyChan := make(chan ai.StreamResponse, 10)
 	traceID := GetTraceID(ctx)
+	alias := ai.ModelAliasFrom(ctx)
 
+	l.pending.Add(1)
 	go func() {
+		defer l.pending.Done()
 		defer close(proxyChan)
 
 		var fullContentBuilder string
@@ -125,13 +158,13 @@ func (l *LoggingMiddleware) GenerateStream(ctx context.Context, req ai.ChatReque
 			proxyChan <- packet
 		}
 
-		l.logStreamSummary(start, req.Model, finalUsage, fullContentBuilder, lastErr, traceID)
+		l.logStreamSummary(start, req, finalUsage, fullContentBuilder, lastErr, traceID, alias)
 	}()
 
 	return proxyChan, nil
 }
 
-func (l *LoggingMiddleware) logStreamSummary(start time.Time, model string, usage *ai.TokenUsage, content string, err error, traceID string) {
+func (l *LoggingMiddleware) logStreamSummary(start time.Time, req ai.ChatRequest, usage *ai.TokenUsage, content string, err error, traceID, alias string) {
 	if l.config.LogErrorsOnly && err == nil {
 		return
 	}
@@ -141,12 +174,17 @@ func (l *LoggingMiddleware) logStreamSummary(start time.Time, model string, usag
 		Timestamp:       start.Add(duration),
 		Duration:        duration,
 		Provider:        l.next.Name(),
-		Model:           model,
+		Model:           ai.ModelOrDefault(l.next, req.Model),
+		ModelAlias:      alias,
 		Operation:       "GenerateStream",
 		Error:           err,
 		ResponsePayload: content,
 		TraceID:         traceID,
 	}
+	if req.Prompt != nil {
+		entry.PromptName = req.Prompt.Name
+		entry.PromptVersion = req.Prompt.Version
+	}
 
 	if usage != nil {
 		entry.InputTokens = usage.InputTokens
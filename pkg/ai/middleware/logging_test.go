@@ -91,3 +91,33 @@ func (m *mockStreamProvider) GenerateStream(ctx context.Context, req ai.ChatRequ
 	}()
 	return ch, nil
 }
+
+type closingProvider struct {
+	*mock.MockClient
+	closed bool
+}
+
+func (c *closingProvider) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestLoggingMiddleware_CloseFlushes(t *testing.T) {
+	inner := &closingProvider{MockClient: mock.NewClient("ok", false)}
+	capture := &CapturingLogger{}
+	mw := NewLoggingMiddleware(inner, capture, logger.Config{})
+
+	if _, err := mw.Generate(context.Background(), ai.ChatRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if capture.CallCount != 1 {
+		t.Errorf("Close returned before the entry was logged: %d", capture.CallCount)
+	}
+	if !inner.closed {
+		t.Error("Close should close the wrapped provider")
+	}
+}
@@ -32,6 +32,10 @@ func (r *RateLimiterMiddleware) Name() string {
 	return r.next.Name()
 }
 
+func (r *RateLimiterMiddleware) Unwrap() ai.AIProvider {
+	return r.next
+}
+
 func (r *RateLimiterMiddleware) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
 	if err := r.limiter.Wait(ctx); err != nil {
 		return nil, err
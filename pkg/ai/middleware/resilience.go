@@ -9,6 +9,9 @@ import (
 	"github.com/ahmettasdemir/gopolyai/pkg/ai"
 )
 
+// DefaultMaxRetries is used when RetryConfig.MaxRetries is not positive.
+const DefaultMaxRetries = 3
+
 type RetryConfig struct {
 	MaxRetries int
 	BaseDelay  time.Duration
@@ -22,7 +25,7 @@ type ResilientClient struct {
 
 func NewResilientClient(p ai.AIProvider, cfg RetryConfig) *ResilientClient {
 	if cfg.MaxRetries <= 0 {
-		cfg.MaxRetries = 3
+		cfg.MaxRetries = DefaultMaxRetries
 	}
 	if cfg.BaseDelay <= 0 {
 		cfg.BaseDelay = 2 * time.Second
@@ -45,6 +48,10 @@ func (r *ResilientClient) Name() string {
 	return fmt.Sprintf("%s (Resilient)", r.provider.Name())
 }
 
+func (r *ResilientClient) Unwrap() ai.AIProvider {
+	return r.provider
+}
+
 func (r *ResilientClient) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
 	var lastErr error
 
@@ -31,6 +31,10 @@ func (t *TracingMiddleware) Name() string {
 	return t.next.Name()
 }
 
+func (t *TracingMiddleware) Unwrap() ai.AIProvider {
+	return t.next
+}
+
 func (t *TracingMiddleware) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
 	ctx = t.ensureTraceID(ctx)
 	return t.next.Generate(ctx, req)
@@ -0,0 +1,192 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// catalog is the fallback for servers whose /api/show predates the
+// capabilities field.
+var catalog = map[string]ai.Capabilities{
+	"llama3":    {Input: []ai.ModelType{ai.ModelText}, ContextWindow: 8_192},
+	"llama3.1":  {Input: []ai.ModelType{ai.ModelText}, Tools: true, ContextWindow: 131_072},
+	"llava":     {Input: []ai.ModelType{ai.ModelText, ai.ModelImage}, ContextWindow: 4_096},
+	"tinyllama": {Input: []ai.ModelType{ai.ModelText}, ContextWindow: 2_048},
+}
+
+// Capabilities asks the server via /api/show, which reports vision and tool
+// support and the model's trained context length.
+func (c *Client) Capabilities(ctx context.Context, model string) (ai.Capabilities, error) {
+	s := c.settings.Load()
+	if model == "" {
+		model = s.Model
+	}
+
+	jsonData, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return ai.Capabilities{}, err
+	}
+
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderOllama)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", apiURL(baseURL, "/api/show"), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return ai.Capabilities{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := s.HTTPClient.Do(httpReq)
+	if err != nil {
+		return ai.Capabilities{}, ai.ErrProviderDown
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return ai.Capabilities{}, fmt.Errorf("ollama show status: %d, body: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var apiResp struct {
+		Capabilities []string               `json:"capabilities"`
+		ModelInfo    map[string]interface{} `json:"model_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return ai.Capabilities{}, err
+	}
+
+	caps, ok := ai.LookupCapabilities(catalog, model)
+	if !ok {
+		caps.Input = []ai.ModelType{ai.ModelText}
+	}
+	if len(apiResp.Capabilities) > 0 {
+		caps.Input = []ai.ModelType{ai.ModelText}
+		caps.Tools = false
+		for _, capability := range apiResp.Capabilities {
+			switch capability {
+			case "vision":
+				caps.Input = append(caps.Input, ai.ModelImage)
+			case "tools":
+				caps.Tools = true
+			}
+		}
+	}
+	for key, v := range apiResp.ModelInfo {
+		if n, isNum := v.(float64); isNum && strings.HasSuffix(key, ".context_length") {
+			caps.ContextWindow = int(n)
+		}
+	}
+
+	caps.Output = []ai.ModelType{ai.ModelText}
+	caps.JSONMode = true
+	caps.Streaming = true
+	caps.StreamUsage = true
+	return caps, nil
+}
+
+// ListModels returns the locally pulled models from /api/tags.
+func (c *Client) ListModels(ctx context.Context) ([]ai.ModelInfo, error) {
+	s := c.settings.Load()
+
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderOllama)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", apiURL(baseURL, "/api/tags"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := s.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, ai.ErrProviderDown
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama tags status: %d, body: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var apiResp struct {
+		Models []struct {
+			Name       string    `json:"name"`
+			ModifiedAt time.Time `json:"modified_at"`
+			Details    struct {
+				Family string `json:"family"`
+			} `json:"details"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+
+	models := make([]ai.ModelInfo, 0, len(apiResp.Models))
+	for _, m := range apiResp.Models {
+		info := ai.ModelInfo{ID: m.Name, OwnedBy: "local", Created: m.ModifiedAt}
+		if caps, ok := ai.LookupCapabilities(catalog, m.Name); ok {
+			info.ContextWindow = caps.ContextWindow
+		}
+		models = append(models, info)
+	}
+	return models, nil
+}
+
+// HealthCheck lists the local models via /api/tags and fails if the
+// configured model has not been pulled.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return err
+	}
+
+	model := c.settings.Load().Model
+	for _, m := range models {
+		if m.ID == model || strings.TrimSuffix(m.ID, ":latest") == model {
+			return nil
+		}
+	}
+	return fmt.Errorf("ollama model %q is not pulled", model)
+}
+
+// Warmup loads the configured model into memory. An empty generate request
+// makes the server load the model without producing output.
+func (c *Client) Warmup(ctx context.Context) error {
+	s := c.settings.Load()
+
+	jsonData, err := json.Marshal(map[string]string{"model": s.Model})
+	if err != nil {
+		return err
+	}
+
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderOllama)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", apiURL(baseURL, "/api/generate"), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := s.HTTPClient.Do(httpReq)
+	if err != nil {
+		return ai.ErrProviderDown
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama warmup status: %d, body: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
@@ -5,39 +5,60 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/ahmettasdemir/gopolyai/pkg/ai"
 )
 
 const defaultBaseURL = "http://localhost:11434/api/chat"
 
+// Client talks to an Ollama server's /api/chat. It needs no API key, and
+// has no timeout by default because the first request may wait for the
+// model to load.
 type Client struct {
-	baseURL string
-	model   string
-	client  *http.Client
+	settings atomic.Pointer[ai.ClientSettings]
+	mu       sync.Mutex
 }
 
-func NewClient() *Client {
-	return &Client{
-		baseURL: defaultBaseURL,
-		model:   "llama3",
-		client:  &http.Client{Timeout: 0},
+// NewClient targets llama3 on localhost:11434; use ai.WithBaseURL for a
+// remote server.
+func NewClient(opts ...ai.Option) *Client {
+	defaults := ai.ClientSettings{
+		Model:      "llama3",
+		BaseURL:    defaultBaseURL,
+		HTTPClient: &http.Client{Timeout: 0},
 	}
+	c := &Client{}
+	c.settings.Store(ai.NewClientSettings(defaults, opts...))
+	return c
+}
+
+// With returns a copy of c with opts applied, e.g. pointing at another
+// host.
+func (c *Client) With(opts ...ai.Option) *Client {
+	next := &Client{}
+	next.settings.Store(c.settings.Load().With(opts...))
+	return next
 }
 
 func (c *Client) Configure(cfg ai.Config) error {
-	if cfg.ModelName != "" {
-		c.model = cfg.ModelName
-	}
-	if cfg.Timeout > 0 {
-		c.client.Timeout = cfg.Timeout
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.settings.Store(c.settings.Load().With(cfg.Options()...))
 	return nil
 }
 
 func (c *Client) Name() string {
-	return "Ollama Local (" + c.model + ")"
+	return "Ollama Local (" + c.settings.Load().Model + ")"
+}
+
+func (c *Client) DefaultModel() string {
+	return c.settings.Load().Model
 }
 
 type ollamaMessage struct {
@@ -46,15 +67,10 @@ type ollamaMessage struct {
 	Images  []string `json:"images,omitempty"`
 }
 
-func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
-	modelToUse := c.model
-	if req.Model != "" {
-		modelToUse = req.Model
-	}
-
+func toOllamaMessages(messages []ai.ChatMessage) []ollamaMessage {
 	var oMessages []ollamaMessage
 
-	for _, msg := range req.Messages {
+	for _, msg := range messages {
 		fullText := ""
 		var images []string
 
@@ -73,53 +89,89 @@ func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResp
 		})
 	}
 
+	return oMessages
+}
+
+// Generate emulates N candidates with concurrent calls; Ollama's chat API
+// returns a single message and no logprobs.
+func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	return ai.GenerateN(ctx, req.N, func(ctx context.Context) (*ai.ChatResponse, error) {
+		return c.generateOnce(ctx, req)
+	})
+}
+
+func (c *Client) generateOnce(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	s := c.settings.Load()
+	req = s.ApplyDefaults(req)
+
+	modelToUse := s.ModelFor(req)
+
 	ollamaReq := map[string]interface{}{
 		"model":    modelToUse,
-		"messages": oMessages,
+		"messages": toOllamaMessages(req.Messages),
 		"stream":   false,
 	}
 
-	if req.Temperature > 0 {
-		ollamaReq["options"] = map[string]float64{
-			"temperature": req.Temperature,
-		}
+	if opts := options(req); len(opts) > 0 {
+		ollamaReq["options"] = opts
+	}
+	if req.JSONMode {
+		ollamaReq["format"] = "json"
 	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	extras := s.RequestExtras(req, ai.ProviderOllama)
+	jsonData, err := ai.MarshalWithExtras(ollamaReq, extras)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewBuffer(jsonData))
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderOllama)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	extras.ApplyHeaders(httpReq.Header)
 
-	resp, err := c.client.Do(httpReq)
+	resp, err := s.HTTPClient.Do(httpReq)
 	if err != nil {
 		return nil, ai.ErrProviderDown
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ollama status: %d", resp.StatusCode)
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama status: %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	var apiResp struct {
+		Model   string `json:"model"`
 		Message struct {
 			Content string `json:"content"`
 		} `json:"message"`
-		PromptEvalCount int `json:"prompt_eval_count"`
-		EvalCount       int `json:"eval_count"`
+		DoneReason      string `json:"done_reason"`
+		PromptEvalCount int    `json:"prompt_eval_count"`
+		EvalCount       int    `json:"eval_count"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
 		return nil, err
 	}
 
+	choice := ai.Choice{
+		Content:      apiResp.Message.Content,
+		FinishReason: doneReason(apiResp.DoneReason),
+	}
+
 	return &ai.ChatResponse{
-		Content: apiResp.Message.Content,
+		Model:        apiResp.Model,
+		Content:      choice.Content,
+		FinishReason: choice.FinishReason,
+		Choices:      []ai.Choice{choice},
+		Unsupported:  ai.UnsupportedParams(req, supportedParams...),
 		Usage: ai.TokenUsage{
 			InputTokens:  apiResp.PromptEvalCount,
 			OutputTokens: apiResp.EvalCount,
@@ -128,60 +180,109 @@ func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResp
 	}, nil
 }
 
-func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
-	streamChan := make(chan ai.StreamResponse, 10)
+// Ollama's options cover every sampling parameter except logprobs.
+var supportedParams = []string{
+	ai.ParamTemperature, ai.ParamTopP, ai.ParamTopK, ai.ParamStop,
+	ai.ParamSeed, ai.ParamPresencePenalty, ai.ParamFrequencyPenalty,
+}
 
-	modelToUse := c.model
-	if req.Model != "" {
-		modelToUse = req.Model
+func options(req ai.ChatRequest) map[string]interface{} {
+	opts := make(map[string]interface{})
+	if req.MaxTokens > 0 {
+		opts["num_predict"] = req.MaxTokens
 	}
+	if req.Temperature != nil {
+		opts["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		opts["top_p"] = *req.TopP
+	}
+	if req.TopK != nil {
+		opts["top_k"] = *req.TopK
+	}
+	if len(req.Stop) > 0 {
+		opts["stop"] = req.Stop
+	}
+	if req.Seed != nil {
+		opts["seed"] = *req.Seed
+	}
+	if req.PresencePenalty != nil {
+		opts["presence_penalty"] = *req.PresencePenalty
+	}
+	if req.FrequencyPenalty != nil {
+		opts["frequency_penalty"] = *req.FrequencyPenalty
+	}
+	return opts
+}
 
-	var oMessages []ollamaMessage
-	for _, msg := range req.Messages {
-		fullText := ""
-		for _, part := range msg.Content {
-			if part.Type == "text" {
-				fullText += part.Text
-			}
-
-		}
-		oMessages = append(oMessages, ollamaMessage{
-			Role:    msg.Role,
-			Content: fullText,
-		})
+func doneReason(reason string) ai.FinishReason {
+	switch reason {
+	case "":
+		return ""
+	case "stop":
+		return ai.FinishStop
+	case "length":
+		return ai.FinishLength
 	}
+	return ai.FinishOther
+}
+
+func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	s := c.settings.Load()
+	req = s.ApplyDefaults(req)
+
+	streamChan := make(chan ai.StreamResponse, 10)
+
+	modelToUse := s.ModelFor(req)
 
 	ollamaReq := map[string]interface{}{
 		"model":    modelToUse,
-		"messages": oMessages,
+		"messages": toOllamaMessages(req.Messages),
 		"stream":   true,
 	}
+	if opts := options(req); len(opts) > 0 {
+		ollamaReq["options"] = opts
+	}
+	if req.JSONMode {
+		ollamaReq["format"] = "json"
+	}
 
-	jsonData, err := json.Marshal(ollamaReq)
+	extras := s.RequestExtras(req, ai.ProviderOllama)
+	jsonData, err := ai.MarshalWithExtras(ollamaReq, extras)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewBuffer(jsonData))
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderOllama)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	extras.ApplyHeaders(httpReq.Header)
 
-	resp, err := c.client.Do(httpReq)
+	resp, err := s.HTTPClient.Do(httpReq)
 	if err != nil {
 		return nil, ai.ErrProviderDown
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("ollama stream status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("ollama stream status: %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	go func() {
 		defer resp.Body.Close()
 		defer close(streamChan)
 
+		if skipped := ai.UnsupportedParams(req, supportedParams...); len(skipped) > 0 {
+			streamChan <- ai.StreamResponse{Unsupported: skipped}
+		}
+
 		decoder := json.NewDecoder(resp.Body)
 
 		for {
@@ -228,3 +329,76 @@ func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan
 
 	return streamChan, nil
 }
+
+// apiURL resolves another Ollama endpoint relative to the chat URL.
+func apiURL(baseURL, path string) string {
+	root := baseURL
+	if idx := strings.Index(root, "/api/"); idx != -1 {
+		root = root[:idx]
+	}
+	return strings.TrimSuffix(root, "/") + path
+}
+
+// CountTokens uses the embed endpoint, which evaluates the prompt and
+// reports prompt_eval_count without generating any output.
+func (c *Client) CountTokens(ctx context.Context, req ai.ChatRequest) (int, error) {
+	s := c.settings.Load()
+
+	modelToUse := s.ModelFor(req)
+
+	var inputs []string
+	for _, msg := range req.Messages {
+		for _, part := range msg.Content {
+			if part.Type == "text" && part.Text != "" {
+				inputs = append(inputs, part.Text)
+			}
+		}
+	}
+	if len(inputs) == 0 {
+		return 0, nil
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"model": modelToUse,
+		"input": strings.Join(inputs, "\n"),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderOllama)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", apiURL(baseURL, "/api/embed"), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := s.HTTPClient.Do(httpReq)
+	if err != nil {
+		return 0, ai.ErrProviderDown
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("ollama embed status: %d, body: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var apiResp struct {
+		PromptEvalCount int `json:"prompt_eval_count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return 0, err
+	}
+
+	return apiResp.PromptEvalCount, nil
+}
+
+func init() {
+	ai.Register(ai.ProviderOllama, func(opts ...ai.Option) (ai.AIProvider, error) {
+		return NewClient(opts...), nil
+	})
+}
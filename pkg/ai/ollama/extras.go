@@ -0,0 +1,20 @@
+package ollama
+
+import "github.com/ahmettasdemir/gopolyai/pkg/ai"
+
+// NumCtx sets the context window Ollama allocates for the model.
+func NumCtx(n int) ai.Extras {
+	return Options(map[string]interface{}{"num_ctx": n})
+}
+
+// KeepAlive controls how long the model stays loaded after the request,
+// e.g. "10m" or "-1" to keep it loaded indefinitely.
+func KeepAlive(d string) ai.Extras {
+	return ai.Extras{Body: map[string]interface{}{"keep_alive": d}}
+}
+
+// Options passes raw model options; they are merged with the sampling
+// options derived from the request.
+func Options(opts map[string]interface{}) ai.Extras {
+	return ai.Extras{Body: map[string]interface{}{"options": opts}}
+}
@@ -0,0 +1,107 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+var (
+	textOnly  = []ai.ModelType{ai.ModelText}
+	textImage = []ai.ModelType{ai.ModelText, ai.ModelImage}
+)
+
+// catalog is keyed by model prefix; the models endpoint does not report
+// capabilities, so these are maintained by hand.
+var catalog = map[string]ai.Capabilities{
+	"gpt-4o":        {Input: textImage, Tools: true, JSONMode: true, ContextWindow: 128_000, MaxOutputTokens: 16_384},
+	"gpt-4-turbo":   {Input: textImage, Tools: true, JSONMode: true, ContextWindow: 128_000, MaxOutputTokens: 4_096},
+	"gpt-4":         {Input: textOnly, Tools: true, ContextWindow: 8_192, MaxOutputTokens: 8_192},
+	"gpt-3.5-turbo": {Input: textOnly, Tools: true, JSONMode: true, ContextWindow: 16_385, MaxOutputTokens: 4_096},
+	"o1":            {Input: textImage, Tools: true, JSONMode: true, ContextWindow: 200_000, MaxOutputTokens: 100_000},
+	"o1-mini":       {Input: textOnly, ContextWindow: 128_000, MaxOutputTokens: 65_536},
+}
+
+func (c *Client) Capabilities(ctx context.Context, model string) (ai.Capabilities, error) {
+	if model == "" {
+		model = c.settings.Load().Model
+	}
+	caps, ok := ai.LookupCapabilities(catalog, model)
+	if !ok {
+		caps.Input = textOnly
+	}
+	caps.Output = textOnly
+	caps.Streaming = true
+	caps.StreamUsage = true
+	return caps, nil
+}
+
+func (c *Client) ListModels(ctx context.Context) ([]ai.ModelInfo, error) {
+	s := c.settings.Load()
+
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderOpenAI)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", modelsURL(baseURL), nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	s.RequestExtras(ai.ChatRequest{}, ai.ProviderOpenAI).ApplyHeaders(httpReq.Header)
+
+	resp, err := s.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, ai.ErrProviderDown
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("openai models status: %d, body: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var apiResp struct {
+		Data []struct {
+			ID      string `json:"id"`
+			Created int64  `json:"created"`
+			OwnedBy string `json:"owned_by"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("json decode error: %w", err)
+	}
+
+	models := make([]ai.ModelInfo, 0, len(apiResp.Data))
+	for _, m := range apiResp.Data {
+		info := ai.ModelInfo{ID: m.ID, OwnedBy: m.OwnedBy}
+		if m.Created > 0 {
+			info.Created = time.Unix(m.Created, 0).UTC()
+		}
+		if caps, ok := ai.LookupCapabilities(catalog, m.ID); ok {
+			info.ContextWindow = caps.ContextWindow
+		}
+		models = append(models, info)
+	}
+	return models, nil
+}
+
+// modelsURL derives the models endpoint from the chat completions URL, so
+// proxies and compatible servers configured via BaseURL keep working.
+func modelsURL(baseURL string) string {
+	root := strings.TrimSuffix(baseURL, "/")
+	if idx := strings.Index(root, "/chat/completions"); idx != -1 {
+		root = root[:idx]
+	}
+	return root + "/models"
+}
+
+// HealthCheck pings the models endpoint, which validates the key and
+// reachability without generating tokens.
+func (c *Client) HealthCheck(ctx context.Context) error {
+	_, err := c.ListModels(ctx)
+	return err
+}
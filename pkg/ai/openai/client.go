@@ -6,8 +6,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ahmettasdemir/gopolyai/pkg/ai"
@@ -15,84 +18,118 @@ import (
 
 const defaultBaseURL = "https://api.openai.com/v1/chat/completions"
 
+// Client talks to the Chat Completions API. BaseURL is the full endpoint,
+// so any OpenAI-compatible server can be used.
 type Client struct {
-	apiKey     string
-	model      string
-	baseURL    string
-	httpClient *http.Client
+	settings atomic.Pointer[ai.ClientSettings]
+	mu       sync.Mutex
 }
 
-func NewClient(apiKey string) *Client {
-	return &Client{
-		apiKey:  apiKey,
-		model:   "gpt-3.5-turbo",
-		baseURL: defaultBaseURL,
-		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
-		},
+// NewClient uses gpt-3.5-turbo with a 60s timeout unless opts say
+// otherwise; apiKey may also be given as ai.WithAPIKey.
+func NewClient(apiKey string, opts ...ai.Option) *Client {
+	defaults := ai.ClientSettings{
+		APIKey:     apiKey,
+		Model:      "gpt-3.5-turbo",
+		BaseURL:    defaultBaseURL,
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
 	}
+	c := &Client{}
+	c.settings.Store(ai.NewClientSettings(defaults, opts...))
+	return c
+}
+
+// With returns a copy of c with opts applied, e.g. another tenant's key.
+func (c *Client) With(opts ...ai.Option) *Client {
+	next := &Client{}
+	next.settings.Store(c.settings.Load().With(opts...))
+	return next
 }
 
 func (c *Client) Configure(cfg ai.Config) error {
-	if cfg.APIKey != "" {
-		c.apiKey = cfg.APIKey
-	}
-	if cfg.ModelName != "" {
-		c.model = cfg.ModelName
-	}
-	if cfg.BaseURL != "" {
-		c.baseURL = cfg.BaseURL
-	}
-	if cfg.Timeout > 0 {
-		c.httpClient.Timeout = cfg.Timeout
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.settings.Store(c.settings.Load().With(cfg.Options()...))
 	return nil
 }
 
 func (c *Client) Name() string {
-	return "OpenAI (" + c.model + ")"
+	return "OpenAI (" + c.settings.Load().Model + ")"
+}
+
+func (c *Client) DefaultModel() string {
+	return c.settings.Load().Model
 }
 
 func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	s := c.settings.Load()
+	req = s.ApplyDefaults(req)
 
 	openaiReq := map[string]interface{}{
-		"model":       c.model,
-		"messages":    req.Messages,
-		"temperature": req.Temperature,
+		"model":    s.ModelFor(req),
+		"messages": req.Messages,
 	}
 
-	if req.Model != "" {
-		openaiReq["model"] = req.Model
+	applySampling(openaiReq, req)
+	if req.JSONMode {
+		openaiReq["response_format"] = map[string]string{"type": "json_object"}
+	}
+	if req.N > 1 {
+		openaiReq["n"] = req.N
+	}
+	if req.Logprobs {
+		openaiReq["logprobs"] = true
+		if req.TopLogprobs > 0 {
+			openaiReq["top_logprobs"] = req.TopLogprobs
+		}
 	}
 
-	jsonData, err := json.Marshal(openaiReq)
+	extras := s.RequestExtras(req, ai.ProviderOpenAI)
+	jsonData, err := ai.MarshalWithExtras(openaiReq, extras)
 	if err != nil {
 		return nil, fmt.Errorf("json marshal error: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewBuffer(jsonData))
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderOpenAI)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	extras.ApplyHeaders(httpReq.Header)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := s.HTTPClient.Do(httpReq)
 	if err != nil {
 		return nil, ai.ErrProviderDown
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-
-		return nil, fmt.Errorf("openai status: %d", resp.StatusCode)
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("openai status: %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	var apiResp struct {
+		ID      string `json:"id"`
+		Model   string `json:"model"`
 		Choices []struct {
+			Index   int `json:"index"`
 			Message struct {
 				Content string `json:"content"`
 			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+			Logprobs     *struct {
+				Content []struct {
+					Token       string  `json:"token"`
+					Logprob     float64 `json:"logprob"`
+					TopLogprobs []struct {
+						Token   string  `json:"token"`
+						Logprob float64 `json:"logprob"`
+					} `json:"top_logprobs"`
+				} `json:"content"`
+			} `json:"logprobs"`
 		} `json:"choices"`
 		Usage struct {
 			PromptTokens     int `json:"prompt_tokens"`
@@ -109,57 +146,147 @@ func (c *Client) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResp
 		return nil, fmt.Errorf("empty response from openai")
 	}
 
-	return &ai.ChatResponse{
-		Content: apiResp.Choices[0].Message.Content,
+	result := &ai.ChatResponse{
+		ID:    apiResp.ID,
+		Model: apiResp.Model,
 		Usage: ai.TokenUsage{
 			InputTokens:  apiResp.Usage.PromptTokens,
 			OutputTokens: apiResp.Usage.CompletionTokens,
 			TotalTokens:  apiResp.Usage.TotalTokens,
 		},
-	}, nil
+	}
+
+	for _, ch := range apiResp.Choices {
+		choice := ai.Choice{
+			Index:        ch.Index,
+			Content:      ch.Message.Content,
+			FinishReason: finishReason(ch.FinishReason),
+		}
+		if choice.FinishReason == ai.FinishContentFilter {
+			choice.Safety = []ai.SafetyRating{{Category: "content_filter", Blocked: true}}
+		}
+		if ch.Logprobs != nil {
+			for _, lp := range ch.Logprobs.Content {
+				tl := ai.TokenLogprob{Token: lp.Token, Logprob: lp.Logprob}
+				for _, top := range lp.TopLogprobs {
+					tl.TopLogprobs = append(tl.TopLogprobs, ai.TopLogprob{Token: top.Token, Logprob: top.Logprob})
+				}
+				choice.Logprobs = append(choice.Logprobs, tl)
+			}
+		}
+		result.Choices = append(result.Choices, choice)
+	}
+
+	result.Content = result.Choices[0].Content
+	result.FinishReason = result.Choices[0].FinishReason
+	result.Safety = result.Choices[0].Safety
+	result.Unsupported = unsupported(req)
+
+	return result, nil
+}
+
+// OpenAI has no top_k. Logprobs are only mapped for non-streaming calls.
+var supportedParams = []string{
+	ai.ParamTemperature, ai.ParamTopP, ai.ParamStop, ai.ParamSeed,
+	ai.ParamPresencePenalty, ai.ParamFrequencyPenalty,
+}
+
+func unsupported(req ai.ChatRequest) []string {
+	return ai.UnsupportedParams(req, append(supportedParams, ai.ParamLogprobs)...)
+}
+
+func applySampling(body map[string]interface{}, req ai.ChatRequest) {
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		body["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		body["top_p"] = *req.TopP
+	}
+	if len(req.Stop) > 0 {
+		body["stop"] = req.Stop
+	}
+	if req.Seed != nil {
+		body["seed"] = *req.Seed
+	}
+	if req.PresencePenalty != nil {
+		body["presence_penalty"] = *req.PresencePenalty
+	}
+	if req.FrequencyPenalty != nil {
+		body["frequency_penalty"] = *req.FrequencyPenalty
+	}
+}
+
+func finishReason(reason string) ai.FinishReason {
+	switch reason {
+	case "":
+		return ""
+	case "stop":
+		return ai.FinishStop
+	case "length":
+		return ai.FinishLength
+	case "content_filter":
+		return ai.FinishContentFilter
+	case "tool_calls", "function_call":
+		return ai.FinishToolCalls
+	}
+	return ai.FinishOther
 }
 
 func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	s := c.settings.Load()
+	req = s.ApplyDefaults(req)
+
 	streamChan := make(chan ai.StreamResponse, 10)
 
 	openaiReq := map[string]interface{}{
-		"model":       c.model,
-		"messages":    req.Messages,
-		"temperature": req.Temperature,
-		"stream":      true,
+		"model":    s.ModelFor(req),
+		"messages": req.Messages,
+		"stream":   true,
 
 		"stream_options": map[string]bool{"include_usage": true},
 	}
-	if req.Model != "" {
-		openaiReq["model"] = req.Model
+	applySampling(openaiReq, req)
+	if req.JSONMode {
+		openaiReq["response_format"] = map[string]string{"type": "json_object"}
 	}
 
-	jsonData, err := json.Marshal(openaiReq)
+	extras := s.RequestExtras(req, ai.ProviderOpenAI)
+	jsonData, err := ai.MarshalWithExtras(openaiReq, extras)
 	if err != nil {
 		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL, bytes.NewBuffer(jsonData))
+	apiKey, baseURL := s.Endpoint(ctx, ai.ProviderOpenAI)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", baseURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	extras.ApplyHeaders(httpReq.Header)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := s.HTTPClient.Do(httpReq)
 	if err != nil {
 		return nil, ai.ErrProviderDown
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, fmt.Errorf("openai stream status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("openai stream status: %d, body: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	go func() {
 		defer resp.Body.Close()
 		defer close(streamChan)
 
+		if skipped := ai.UnsupportedParams(req, supportedParams...); len(skipped) > 0 {
+			streamChan <- ai.StreamResponse{Unsupported: skipped}
+		}
+
 		scanner := bufio.NewScanner(resp.Body)
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -211,3 +338,9 @@ func (c *Client) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan
 
 	return streamChan, nil
 }
+
+func init() {
+	ai.Register(ai.ProviderOpenAI, func(opts ...ai.Option) (ai.AIProvider, error) {
+		return NewClient("", opts...), nil
+	})
+}
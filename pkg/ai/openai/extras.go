@@ -0,0 +1,16 @@
+package openai
+
+import "github.com/ahmettasdemir/gopolyai/pkg/ai"
+
+// User tags requests with a stable end-user identifier for abuse monitoring.
+func User(id string) ai.Extras {
+	return ai.Extras{Body: map[string]interface{}{"user": id}}
+}
+
+func Organization(org string) ai.Extras {
+	return ai.Extras{Headers: map[string]string{"OpenAI-Organization": org}}
+}
+
+func Project(project string) ai.Extras {
+	return ai.Extras{Headers: map[string]string{"OpenAI-Project": project}}
+}
@@ -0,0 +1,84 @@
+package ai_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/ollama"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/openai"
+)
+
+func TestFunctionalOptions(t *testing.T) {
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Team")
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	base := openai.NewClient("test-key",
+		ai.WithBaseURL(server.URL),
+		ai.WithModel("gpt-4o"),
+		ai.WithHeaders(map[string]string{"X-Team": "search"}),
+		ai.WithHTTPClient(server.Client()),
+	)
+	if base.Name() != "OpenAI (gpt-4o)" {
+		t.Errorf("model option not applied: %s", base.Name())
+	}
+
+	if _, err := base.Generate(context.Background(), ai.ChatRequest{}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if gotHeader != "search" {
+		t.Errorf("static header not sent, got %q", gotHeader)
+	}
+
+	derived := base.With(ai.WithModel("gpt-4o-mini"))
+	if derived.Name() != "OpenAI (gpt-4o-mini)" || base.Name() != "OpenAI (gpt-4o)" {
+		t.Errorf("With must return an independent client: base=%s derived=%s", base.Name(), derived.Name())
+	}
+}
+
+func TestOllamaConfigureHonorsBaseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message": {"content": "local"}, "done": true}`))
+	}))
+	defer server.Close()
+
+	client := ollama.NewClient()
+	client.Configure(ai.Config{BaseURL: server.URL + "/api/chat"})
+
+	resp, err := client.Generate(context.Background(), ai.ChatRequest{})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if resp.Content != "local" {
+		t.Errorf("unexpected content: %q", resp.Content)
+	}
+}
+
+func TestConfigureWhileServing(t *testing.T) {
+	server := openai.StartMockServer()
+	defer server.Close()
+
+	client := openai.NewClient("test-key", ai.WithBaseURL(server.URL))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client.Generate(context.Background(), ai.ChatRequest{})
+		}()
+		go func() {
+			defer wg.Done()
+			client.Configure(ai.Config{ModelName: "gpt-4o", Timeout: 5 * time.Second})
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,65 @@
+package ai
+
+func Float64(v float64) *float64 { return &v }
+func Int(v int) *int             { return &v }
+func Int64(v int64) *int64       { return &v }
+
+const (
+	ParamTemperature      = "temperature"
+	ParamTopP             = "top_p"
+	ParamTopK             = "top_k"
+	ParamStop             = "stop"
+	ParamSeed             = "seed"
+	ParamPresencePenalty  = "presence_penalty"
+	ParamFrequencyPenalty = "frequency_penalty"
+	ParamLogprobs         = "logprobs"
+)
+
+// SetParams returns the names of the optional parameters set on the request.
+func (r ChatRequest) SetParams() []string {
+	var set []string
+	if r.Temperature != nil {
+		set = append(set, ParamTemperature)
+	}
+	if r.TopP != nil {
+		set = append(set, ParamTopP)
+	}
+	if r.TopK != nil {
+		set = append(set, ParamTopK)
+	}
+	if len(r.Stop) > 0 {
+		set = append(set, ParamStop)
+	}
+	if r.Seed != nil {
+		set = append(set, ParamSeed)
+	}
+	if r.PresencePenalty != nil {
+		set = append(set, ParamPresencePenalty)
+	}
+	if r.FrequencyPenalty != nil {
+		set = append(set, ParamFrequencyPenalty)
+	}
+	if r.Logprobs {
+		set = append(set, ParamLogprobs)
+	}
+	return set
+}
+
+// UnsupportedParams returns the parameters set on req that are missing from
+// the provider's supported list.
+func UnsupportedParams(req ChatRequest, supported ...string) []string {
+	var out []string
+	for _, p := range req.SetParams() {
+		ok := false
+		for _, s := range supported {
+			if p == s {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
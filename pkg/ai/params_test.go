@@ -0,0 +1,110 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/anthropic"
+)
+
+func TestAnthropicSamplingParams(t *testing.T) {
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content": [{"type": "text", "text": "ok"}], "stop_reason": "end_turn"}`))
+	}))
+	defer server.Close()
+
+	client := anthropic.NewClient("test-key")
+	client.Configure(ai.Config{BaseURL: server.URL})
+
+	resp, err := client.Generate(context.Background(), ai.ChatRequest{
+		Temperature: ai.Float64(0),
+		TopK:        ai.Int(40),
+		Stop:        []string{"END"},
+		Seed:        ai.Int64(7),
+	})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if temp, ok := sent["temperature"]; !ok || temp != float64(0) {
+		t.Errorf("explicit zero temperature not sent: %v", sent)
+	}
+	if sent["top_k"] != float64(40) {
+		t.Errorf("top_k not sent: %v", sent)
+	}
+	if _, ok := sent["top_p"]; ok {
+		t.Error("unset top_p should be omitted")
+	}
+	if !reflect.DeepEqual(resp.Unsupported, []string{ai.ParamSeed}) {
+		t.Errorf("expected seed to be reported unsupported, got %v", resp.Unsupported)
+	}
+}
+
+func TestSetParams(t *testing.T) {
+	req := ai.ChatRequest{}
+	if len(req.SetParams()) != 0 {
+		t.Errorf("expected no params on empty request, got %v", req.SetParams())
+	}
+
+	req.Temperature = ai.Float64(0)
+	req.PresencePenalty = ai.Float64(0.5)
+	want := []string{ai.ParamTemperature, ai.ParamPresencePenalty}
+	if got := req.SetParams(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestConfigTemperatureZeroIsHonored(t *testing.T) {
+	s := ai.NewClientSettings(ai.ClientSettings{}, ai.Config{Temperature: ai.Float64(0)}.Options()...)
+	if s.Temperature == nil || *s.Temperature != 0 {
+		t.Errorf("explicit zero temperature dropped: %v", s.Temperature)
+	}
+	if s = ai.NewClientSettings(ai.ClientSettings{}, ai.Config{}.Options()...); s.Temperature != nil {
+		t.Errorf("unset temperature should stay nil, got %v", *s.Temperature)
+	}
+}
+
+func TestAnthropicSystemPrompt(t *testing.T) {
+	var sent struct {
+		System   string `json:"system"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content": [{"type": "text", "text": "ok"}], "stop_reason": "end_turn"}`))
+	}))
+	defer server.Close()
+
+	client := anthropic.NewClient("test-key", ai.WithBaseURL(server.URL))
+	text := func(role, s string) ai.ChatMessage {
+		return ai.ChatMessage{Role: role, Content: []ai.Content{{Type: "text", Text: s}}}
+	}
+	_, err := client.Generate(context.Background(), ai.ChatRequest{Messages: []ai.ChatMessage{
+		text("system", "be brief"),
+		text("system", "answer in French"),
+		text("user", "hi"),
+		text("system", "now in German"),
+	}})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if sent.System != "be brief\n\nanswer in French" {
+		t.Errorf("system = %q", sent.System)
+	}
+	if len(sent.Messages) != 2 || sent.Messages[0].Role != "user" || sent.Messages[1].Role != "user" {
+		t.Errorf("messages = %+v", sent.Messages)
+	}
+}
@@ -0,0 +1,206 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrNotFound = errors.New("prompt template not found")
+
+// Registry holds every version of every template. An empty version or
+// "latest" selects the highest registered version.
+type Registry struct {
+	mu        sync.RWMutex
+	templates map[string]map[string]*Template
+}
+
+func NewRegistry() *Registry {
+	return &Registry{templates: make(map[string]map[string]*Template)}
+}
+
+func (r *Registry) Register(t *Template) error {
+	if err := t.Compile(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, ok := r.templates[t.Name]
+	if !ok {
+		versions = make(map[string]*Template)
+		r.templates[t.Name] = versions
+	}
+	if _, exists := versions[t.Version]; exists {
+		return fmt.Errorf("prompt template %s@%s already registered", t.Name, t.Version)
+	}
+	versions[t.Version] = t
+	return nil
+}
+
+func (r *Registry) Get(name, version string) (*Template, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	if version == "" || version == "latest" {
+		var latest *Template
+		for _, t := range versions {
+			if latest == nil || compareVersions(t.Version, latest.Version) > 0 {
+				latest = t
+			}
+		}
+		return latest, nil
+	}
+
+	t, ok := versions[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s@%s", ErrNotFound, name, version)
+	}
+	return t, nil
+}
+
+func (r *Registry) Render(name, version string, vars map[string]interface{}) (ai.ChatRequest, error) {
+	t, err := r.Get(name, version)
+	if err != nil {
+		return ai.ChatRequest{}, err
+	}
+	return t.Render(vars)
+}
+
+// Versions lists the registered versions of name, oldest first.
+func (r *Registry) Versions(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []string
+	for v := range r.templates[name] {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return compareVersions(out[i], out[j]) < 0 })
+	return out
+}
+
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []string
+	for name := range r.templates {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// LoadDir registers every template found in the .yaml/.yml files of dir.
+// A file may hold several templates as separate YAML documents.
+func (r *Registry) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		if err := r.LoadFile(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	for {
+		var t Template
+		err := decoder.Decode(&t)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := r.Register(&t); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+}
+
+var Default = NewRegistry()
+
+func Register(t *Template) error {
+	return Default.Register(t)
+}
+
+func Render(name, version string, vars map[string]interface{}) (ai.ChatRequest, error) {
+	return Default.Render(name, version, vars)
+}
+
+func LoadDir(dir string) error {
+	return Default.LoadDir(dir)
+}
+
+// compareVersions orders dotted versions numerically ("1.10" > "1.9") and
+// falls back to string comparison for non-numeric segments.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		if x == "" {
+			xn, xerr = 0, nil
+		}
+		if y == "" {
+			yn, yerr = 0, nil
+		}
+
+		if xerr == nil && yerr == nil {
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,123 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const summarizeYAML = `name: summarize
+version: "1.2"
+model: gpt-4o
+variables:
+  - name: text
+    type: string
+    required: true
+  - name: max_words
+    type: int
+    default: 50
+messages:
+  - role: system
+    content: "You write summaries."
+  - role: user
+    content: "Summarize in {{.max_words}} words: {{.text}}"
+---
+name: summarize
+version: "1.10"
+variables:
+  - name: text
+    required: true
+messages:
+  - role: user
+    content: "TL;DR: {{.text}}"
+`
+
+func TestLoadDirAndRender(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "summarize.yaml"), []byte(summarizeYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry()
+	if err := r.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir failed: %v", err)
+	}
+
+	req, err := r.Render("summarize", "1.2", map[string]interface{}{"text": "Go is fun."})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if req.Model != "gpt-4o" || len(req.Messages) != 2 {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	if got := req.Messages[1].Content[0].Text; got != "Summarize in 50 words: Go is fun." {
+		t.Errorf("unexpected rendered text: %q", got)
+	}
+	if req.Prompt == nil || req.Prompt.Name != "summarize" || req.Prompt.Version != "1.2" {
+		t.Errorf("prompt reference not set: %+v", req.Prompt)
+	}
+
+	latest, err := r.Render("summarize", "", map[string]interface{}{"text": "x"})
+	if err != nil {
+		t.Fatalf("Render latest failed: %v", err)
+	}
+	if latest.Prompt.Version != "1.10" {
+		t.Errorf("expected latest version 1.10, got %s", latest.Prompt.Version)
+	}
+}
+
+func TestRenderValidatesVariables(t *testing.T) {
+	r := NewRegistry()
+	err := r.Register(&Template{
+		Name:      "greet",
+		Version:   "1",
+		Variables: []Variable{{Name: "name", Required: true}, {Name: "times", Type: TypeInt}},
+		Messages:  []MessageTemplate{{Role: "user", Content: "Hello {{.name}} x{{.times}}"}},
+	})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		vars map[string]interface{}
+		want string
+	}{
+		{"missing required", map[string]interface{}{}, "missing required"},
+		{"wrong type", map[string]interface{}{"name": "Ada", "times": "two"}, "must be int"},
+		{"undeclared", map[string]interface{}{"name": "Ada", "extra": 1}, "undeclared"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := r.Render("greet", "1", tc.vars)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestConcurrentFirstRender(t *testing.T) {
+	tmpl := &Template{
+		Name:      "greet",
+		Version:   "1",
+		Variables: []Variable{{Name: "name"}},
+		Messages:  []MessageTemplate{{Role: "user", Content: "Hello {{.name}}"}},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := tmpl.Render(map[string]interface{}{"name": "Ada"})
+			if err != nil || req.Messages[0].Content[0].Text != "Hello Ada" {
+				t.Errorf("Render: %v %+v", err, req)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,233 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+type VarType string
+
+const (
+	TypeString VarType = "string"
+	TypeInt    VarType = "int"
+	TypeFloat  VarType = "float"
+	TypeBool   VarType = "bool"
+	TypeList   VarType = "list"
+)
+
+type Variable struct {
+	Name        string      `yaml:"name"`
+	Type        VarType     `yaml:"type"`
+	Required    bool        `yaml:"required"`
+	Default     interface{} `yaml:"default"`
+	Description string      `yaml:"description"`
+}
+
+type MessageTemplate struct {
+	Role    string `yaml:"role"`
+	Content string `yaml:"content"`
+}
+
+// Template is a named, versioned prompt. Message contents are Go
+// text/template sources evaluated against the typed variables.
+type Template struct {
+	Name        string            `yaml:"name"`
+	Version     string            `yaml:"version"`
+	Description string            `yaml:"description"`
+	Model       string            `yaml:"model"`
+	Variables   []Variable        `yaml:"variables"`
+	Messages    []MessageTemplate `yaml:"messages"`
+
+	// once guards the lazy Compile in Render, so concurrent first renders
+	// of an unregistered template do not race.
+	once       sync.Once
+	compiled   []*template.Template
+	compileErr error
+}
+
+// Compile validates the template and parses its message sources. Registry
+// calls it on registration; call it before sharing a template that is not
+// registered, or let the first Render do it.
+func (t *Template) Compile() error {
+	if t.Name == "" {
+		return fmt.Errorf("prompt template: name is required")
+	}
+	if t.Version == "" {
+		return fmt.Errorf("prompt template %s: version is required", t.Name)
+	}
+	if len(t.Messages) == 0 {
+		return fmt.Errorf("prompt template %s@%s: at least one message is required", t.Name, t.Version)
+	}
+
+	seen := make(map[string]bool)
+	for i, v := range t.Variables {
+		if v.Name == "" {
+			return fmt.Errorf("prompt template %s@%s: variable %d has no name", t.Name, t.Version, i)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("prompt template %s@%s: duplicate variable %q", t.Name, t.Version, v.Name)
+		}
+		seen[v.Name] = true
+
+		if v.Type == "" {
+			t.Variables[i].Type = TypeString
+		}
+		if !validType(t.Variables[i].Type) {
+			return fmt.Errorf("prompt template %s@%s: variable %q has unknown type %q", t.Name, t.Version, v.Name, v.Type)
+		}
+		if v.Default != nil {
+			if err := checkType(t.Variables[i], v.Default); err != nil {
+				return fmt.Errorf("prompt template %s@%s: default: %w", t.Name, t.Version, err)
+			}
+		}
+	}
+
+	compiled := make([]*template.Template, len(t.Messages))
+	for i, m := range t.Messages {
+		if m.Role == "" {
+			return fmt.Errorf("prompt template %s@%s: message %d has no role", t.Name, t.Version, i)
+		}
+		tmpl, err := template.New(fmt.Sprintf("%s@%s#%d", t.Name, t.Version, i)).
+			Option("missingkey=error").
+			Parse(m.Content)
+		if err != nil {
+			return fmt.Errorf("prompt template %s@%s: message %d: %w", t.Name, t.Version, i, err)
+		}
+		compiled[i] = tmpl
+	}
+
+	t.compiled = compiled
+	return nil
+}
+
+// Render checks vars against the declared variables and returns a request
+// tagged with the template's name and version.
+func (t *Template) Render(vars map[string]interface{}) (ai.ChatRequest, error) {
+	t.once.Do(func() {
+		if t.compiled == nil {
+			t.compileErr = t.Compile()
+		}
+	})
+	if t.compileErr != nil {
+		return ai.ChatRequest{}, t.compileErr
+	}
+
+	data, err := t.bind(vars)
+	if err != nil {
+		return ai.ChatRequest{}, err
+	}
+
+	req := ai.ChatRequest{
+		Model:  t.Model,
+		Prompt: &ai.PromptRef{Name: t.Name, Version: t.Version},
+	}
+
+	for i, tmpl := range t.compiled {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return ai.ChatRequest{}, fmt.Errorf("render %s@%s: %w", t.Name, t.Version, err)
+		}
+		req.Messages = append(req.Messages, ai.ChatMessage{
+			Role:    t.Messages[i].Role,
+			Content: []ai.Content{{Type: "text", Text: buf.String()}},
+		})
+	}
+
+	return req, nil
+}
+
+func (t *Template) bind(vars map[string]interface{}) (map[string]interface{}, error) {
+	data := make(map[string]interface{}, len(t.Variables))
+	declared := make(map[string]bool, len(t.Variables))
+
+	for _, v := range t.Variables {
+		declared[v.Name] = true
+
+		val, ok := vars[v.Name]
+		if !ok {
+			if v.Required {
+				return nil, fmt.Errorf("render %s@%s: missing required variable %q", t.Name, t.Version, v.Name)
+			}
+			val = v.Default
+			if val == nil {
+				val = zeroValue(v.Type)
+			}
+		}
+
+		if err := checkType(v, val); err != nil {
+			return nil, fmt.Errorf("render %s@%s: %w", t.Name, t.Version, err)
+		}
+		data[v.Name] = val
+	}
+
+	var unknown []string
+	for name := range vars {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("render %s@%s: undeclared variables: %s", t.Name, t.Version, strings.Join(unknown, ", "))
+	}
+
+	return data, nil
+}
+
+func validType(t VarType) bool {
+	switch t {
+	case TypeString, TypeInt, TypeFloat, TypeBool, TypeList:
+		return true
+	}
+	return false
+}
+
+func zeroValue(t VarType) interface{} {
+	switch t {
+	case TypeInt:
+		return 0
+	case TypeFloat:
+		return 0.0
+	case TypeBool:
+		return false
+	case TypeList:
+		return []interface{}{}
+	}
+	return ""
+}
+
+func checkType(v Variable, val interface{}) error {
+	kind := reflect.ValueOf(val).Kind()
+
+	ok := false
+	switch v.Type {
+	case TypeString:
+		ok = kind == reflect.String
+	case TypeInt:
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			ok = true
+		}
+	case TypeFloat:
+		switch kind {
+		case reflect.Float32, reflect.Float64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			ok = true
+		}
+	case TypeBool:
+		ok = kind == reflect.Bool
+	case TypeList:
+		ok = kind == reflect.Slice || kind == reflect.Array
+	}
+
+	if !ok {
+		return fmt.Errorf("variable %q must be %s, got %T", v.Name, v.Type, val)
+	}
+	return nil
+}
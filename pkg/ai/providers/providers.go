@@ -0,0 +1,12 @@
+// Package providers registers every built-in provider with
+// ai.DefaultRegistry. Import it for its side effects:
+//
+//	import _ "github.com/ahmettasdemir/gopolyai/pkg/ai/providers"
+package providers
+
+import (
+	_ "github.com/ahmettasdemir/gopolyai/pkg/ai/anthropic"
+	_ "github.com/ahmettasdemir/gopolyai/pkg/ai/google"
+	_ "github.com/ahmettasdemir/gopolyai/pkg/ai/ollama"
+	_ "github.com/ahmettasdemir/gopolyai/pkg/ai/openai"
+)
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory builds a provider from options. Provider packages register one
+// per name, normally from init.
+type Factory func(opts ...Option) (AIProvider, error)
+
+// ProviderSpec is anything that can describe a client as options, such as
+// Config or config.Provider.
+type ProviderSpec interface {
+	Options() []Option
+}
+
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+	aliases   Aliases
+}
+
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory), aliases: make(Aliases)}
+}
+
+// Register adds a factory under name. Like database/sql drivers, registering
+// the same name twice is a programming error and panics.
+func (r *Registry) Register(name string, f Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f == nil {
+		panic("ai: Register factory is nil for " + name)
+	}
+	if _, dup := r.factories[name]; dup {
+		panic("ai: Register called twice for provider " + name)
+	}
+	r.factories[name] = f
+}
+
+// New builds the provider registered as name from spec. Extra options are
+// applied after the spec's, e.g. a shared HTTP client.
+func (r *Registry) New(name string, spec ProviderSpec, opts ...Option) (AIProvider, error) {
+	r.mu.RLock()
+	f, ok := r.factories[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q (registered: %s)", name, strings.Join(r.Names(), ", "))
+	}
+
+	var all []Option
+	if spec != nil {
+		all = append(all, spec.Options()...)
+	}
+	all = append(all, opts...)
+	return f(all...)
+}
+
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterAlias maps a logical model name to a provider and model. Unlike
+// Register it may be called again to repoint an alias.
+func (r *Registry) RegisterAlias(name string, a Alias) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[name] = a
+}
+
+// Aliases returns a copy of the registered aliases.
+func (r *Registry) Aliases() Aliases {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	aliases := make(Aliases, len(r.aliases))
+	for name, a := range r.aliases {
+		aliases[name] = a
+	}
+	return aliases
+}
+
+// DefaultRegistry holds the built-in providers once their packages are
+// imported; see pkg/ai/providers.
+var DefaultRegistry = NewRegistry()
+
+func Register(name string, f Factory) { DefaultRegistry.Register(name, f) }
+
+func New(name string, spec ProviderSpec, opts ...Option) (AIProvider, error) {
+	return DefaultRegistry.New(name, spec, opts...)
+}
+
+func Providers() []string { return DefaultRegistry.Names() }
+
+func RegisterAlias(name string, a Alias) { DefaultRegistry.RegisterAlias(name, a) }
@@ -0,0 +1,77 @@
+package ai_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/mock"
+	"github.com/ahmettasdemir/gopolyai/pkg/config"
+)
+
+func TestRegistryThirdPartyProvider(t *testing.T) {
+	reg := ai.NewRegistry()
+	var gotModel string
+	reg.Register("acme", func(opts ...ai.Option) (ai.AIProvider, error) {
+		s := ai.NewClientSettings(ai.ClientSettings{}, opts...)
+		gotModel = s.Model
+		return mock.NewClient("acme says hi", false), nil
+	})
+
+	p, err := reg.New("acme", ai.Config{ModelName: "acme-1"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if gotModel != "acme-1" || p.Name() != "Mock AI" {
+		t.Errorf("factory did not receive spec options: model=%q", gotModel)
+	}
+
+	if _, err := reg.New("missing", nil); err == nil || !strings.Contains(err.Error(), "acme") {
+		t.Errorf("unknown provider error should list registered names, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate Register should panic")
+		}
+	}()
+	reg.Register("acme", func(opts ...ai.Option) (ai.AIProvider, error) { return nil, nil })
+}
+
+func TestBuiltinProvidersFromConfig(t *testing.T) {
+	for _, name := range []string{ai.ProviderOpenAI, ai.ProviderAnthropic, ai.ProviderGoogle, ai.ProviderOllama} {
+		p, err := ai.New(name, config.Provider{APIKey: "k", Model: "m-1"})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !strings.Contains(p.Name(), "m-1") {
+			t.Errorf("%s: model not applied, name %q", name, p.Name())
+		}
+	}
+}
+
+func TestConfigNewProviderUsesType(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{Providers: map[string]config.Provider{
+		"groq": {Type: "openai", APIKey: "gsk", BaseURL: server.URL, Model: "llama-3.1-8b"},
+	}}
+	p, err := cfg.NewProvider("groq")
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	if _, err := p.Generate(context.Background(), ai.ChatRequest{}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if auth != "Bearer gsk" {
+		t.Errorf("openai-compatible provider not built from config, auth %q", auth)
+	}
+}
@@ -0,0 +1,112 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Router is an ordered failover across any number of providers. Members
+// known to be unhealthy (see HealthReporter) are skipped, and only tried as
+// a last resort once every healthy member has failed.
+type Router struct {
+	providers []AIProvider
+}
+
+func NewRouter(providers ...AIProvider) *Router {
+	return &Router{providers: providers}
+}
+
+func (r *Router) Providers() []AIProvider {
+	return r.providers
+}
+
+func (r *Router) Configure(cfg Config) error {
+	for _, p := range r.providers {
+		if err := p.Configure(cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Router) Name() string {
+	names := make([]string, len(r.providers))
+	for i, p := range r.providers {
+		names[i] = p.Name()
+	}
+	return fmt.Sprintf("Router (%s)", strings.Join(names, " -> "))
+}
+
+// Current returns the provider the next request will try first.
+func (r *Router) Current() AIProvider {
+	order := r.order()
+	if len(order) == 0 {
+		return nil
+	}
+	return order[0]
+}
+
+func (r *Router) Generate(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	var errs []error
+	for _, p := range r.order() {
+		resp, err := p.Generate(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, r.failure(errs)
+}
+
+// GenerateStream fails over only while opening the stream; once chunks flow
+// the stream is committed to one provider.
+func (r *Router) GenerateStream(ctx context.Context, req ChatRequest) (<-chan StreamResponse, error) {
+	var errs []error
+	for _, p := range r.order() {
+		stream, err := p.GenerateStream(ctx, req)
+		if err == nil {
+			return stream, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, r.failure(errs)
+}
+
+// Close releases members that hold resources, such as health probers.
+func (r *Router) Close() error {
+	var errs []error
+	for _, p := range r.providers {
+		if c, ok := As[io.Closer](p); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Router) order() []AIProvider {
+	var up, down []AIProvider
+	for _, p := range r.providers {
+		if healthy(p) {
+			up = append(up, p)
+		} else {
+			down = append(down, p)
+		}
+	}
+	return append(up, down...)
+}
+
+func (r *Router) failure(errs []error) error {
+	if len(errs) == 0 {
+		return fmt.Errorf("%w: router has no providers", ErrProviderDown)
+	}
+	return fmt.Errorf("%w: all providers failed: %w", ErrProviderDown, errors.Join(errs...))
+}
@@ -0,0 +1,67 @@
+package ai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+type routedProvider struct {
+	name  string
+	down  bool
+	fail  bool
+	calls int
+}
+
+func (p *routedProvider) Configure(cfg ai.Config) error { return nil }
+func (p *routedProvider) Name() string                  { return p.name }
+func (p *routedProvider) Healthy() bool                 { return !p.down }
+func (p *routedProvider) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	p.calls++
+	if p.fail {
+		return nil, errors.New(p.name + " exploded")
+	}
+	return &ai.ChatResponse{Content: p.name}, nil
+}
+func (p *routedProvider) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestRouterSkipsUnhealthyAndFailsOver(t *testing.T) {
+	a := &routedProvider{name: "a", down: true}
+	b := &routedProvider{name: "b", fail: true}
+	c := &routedProvider{name: "c"}
+	router := ai.NewRouter(a, b, c)
+
+	if router.Current() != b {
+		t.Errorf("first healthy provider should be current, got %s", router.Current().Name())
+	}
+
+	resp, err := router.Generate(context.Background(), ai.ChatRequest{})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if resp.Content != "c" || a.calls != 0 || b.calls != 1 {
+		t.Errorf("unexpected routing: resp=%s a=%d b=%d", resp.Content, a.calls, b.calls)
+	}
+}
+
+func TestRouterTriesUnhealthyAsLastResort(t *testing.T) {
+	a := &routedProvider{name: "a", fail: true}
+	b := &routedProvider{name: "b", down: true}
+	router := ai.NewRouter(a, b)
+
+	resp, err := router.Generate(context.Background(), ai.ChatRequest{})
+	if err != nil || resp.Content != "b" {
+		t.Fatalf("expected last-resort answer from b, got %v %v", resp, err)
+	}
+
+	b.fail = true
+	_, err = router.Generate(context.Background(), ai.ChatRequest{})
+	if !errors.Is(err, ai.ErrProviderDown) || !strings.Contains(err.Error(), "a exploded") || !strings.Contains(err.Error(), "b exploded") {
+		t.Errorf("expected joined provider errors, got %v", err)
+	}
+}
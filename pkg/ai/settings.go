@@ -0,0 +1,182 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ClientSettings is the immutable snapshot a provider client works from.
+// Configure and the functional options never modify a snapshot in place;
+// they build a new one, so in-flight calls keep a consistent view. Clients
+// keep theirs in an atomic.Pointer and Configure swaps it, which makes
+// Configure safe while the client is serving traffic, and With derives a
+// new client without touching the original.
+type ClientSettings struct {
+	APIKey     string
+	BaseURL    string
+	Model      string
+	HTTPClient *http.Client
+	Headers    map[string]string
+	Extras     Extras
+
+	// Temperature and MaxTokens are used when a request leaves them unset.
+	Temperature *float64
+	MaxTokens   int
+}
+
+type Option func(*ClientSettings)
+
+func WithAPIKey(key string) Option {
+	return func(s *ClientSettings) { s.APIKey = key }
+}
+
+func WithBaseURL(url string) Option {
+	return func(s *ClientSettings) { s.BaseURL = url }
+}
+
+func WithModel(model string) Option {
+	return func(s *ClientSettings) { s.Model = model }
+}
+
+// WithHTTPClient replaces the client's http.Client. The client is used as
+// is and never mutated.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(s *ClientSettings) { s.HTTPClient = hc }
+}
+
+// WithHeaders adds headers sent on every request.
+func WithHeaders(headers map[string]string) Option {
+	return func(s *ClientSettings) {
+		merged := make(map[string]string, len(s.Headers)+len(headers))
+		for k, v := range s.Headers {
+			merged[k] = v
+		}
+		for k, v := range headers {
+			merged[k] = v
+		}
+		s.Headers = merged
+	}
+}
+
+// WithTransport swaps the RoundTripper while keeping the current timeout,
+// e.g. one built by the transport package.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(s *ClientSettings) {
+		hc := &http.Client{}
+		if s.HTTPClient != nil {
+			*hc = *s.HTTPClient
+		}
+		hc.Transport = rt
+		s.HTTPClient = hc
+	}
+}
+
+func WithTimeout(d time.Duration) Option {
+	return func(s *ClientSettings) {
+		hc := &http.Client{}
+		if s.HTTPClient != nil {
+			*hc = *s.HTTPClient
+		}
+		hc.Timeout = d
+		s.HTTPClient = hc
+	}
+}
+
+// WithTemperature sets the default temperature; an explicit 0 is honored.
+func WithTemperature(t float64) Option {
+	return func(s *ClientSettings) { s.Temperature = &t }
+}
+
+func WithMaxTokens(n int) Option {
+	return func(s *ClientSettings) { s.MaxTokens = n }
+}
+
+func WithExtras(e Extras) Option {
+	return func(s *ClientSettings) { s.Extras = e }
+}
+
+// NewClientSettings applies opts over defaults.
+func NewClientSettings(defaults ClientSettings, opts ...Option) *ClientSettings {
+	s := defaults
+	if s.HTTPClient == nil {
+		s.HTTPClient = &http.Client{}
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return &s
+}
+
+// With returns a copy of s with opts applied.
+func (s *ClientSettings) With(opts ...Option) *ClientSettings {
+	return NewClientSettings(*s, opts...)
+}
+
+// Options translates a Config into options; empty fields keep the current
+// value, matching the historical Configure semantics.
+func (cfg Config) Options() []Option {
+	var opts []Option
+	if cfg.APIKey != "" {
+		opts = append(opts, WithAPIKey(cfg.APIKey))
+	}
+	if cfg.ModelName != "" {
+		opts = append(opts, WithModel(cfg.ModelName))
+	}
+	if cfg.BaseURL != "" {
+		opts = append(opts, WithBaseURL(cfg.BaseURL))
+	}
+	if cfg.Timeout > 0 {
+		opts = append(opts, WithTimeout(cfg.Timeout))
+	}
+	if cfg.Temperature != nil {
+		opts = append(opts, WithTemperature(*cfg.Temperature))
+	}
+	if cfg.MaxTokens > 0 {
+		opts = append(opts, WithMaxTokens(cfg.MaxTokens))
+	}
+	if !cfg.Extras.IsEmpty() {
+		opts = append(opts, WithExtras(cfg.Extras))
+	}
+	return opts
+}
+
+func (s *ClientSettings) ModelFor(req ChatRequest) string {
+	if req.Model != "" {
+		return req.Model
+	}
+	return s.Model
+}
+
+// ApplyDefaults fills the sampling fields req leaves unset from the client
+// defaults.
+func (s *ClientSettings) ApplyDefaults(req ChatRequest) ChatRequest {
+	if req.Temperature == nil && s.Temperature != nil {
+		req.Temperature = s.Temperature
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = s.MaxTokens
+	}
+	return req
+}
+
+// Endpoint resolves the API key and base URL for one call, honoring
+// credentials attached with WithCredentials.
+func (s *ClientSettings) Endpoint(ctx context.Context, provider string) (apiKey, baseURL string) {
+	apiKey, baseURL = s.APIKey, s.BaseURL
+	if creds, ok := CredentialsFrom(ctx, provider); ok {
+		if creds.APIKey != "" {
+			apiKey = creds.APIKey
+		}
+		if creds.BaseURL != "" {
+			baseURL = creds.BaseURL
+		}
+	}
+	return apiKey, baseURL
+}
+
+// RequestExtras layers static headers, client extras and the request's
+// extras for provider, in that order.
+func (s *ClientSettings) RequestExtras(req ChatRequest, provider string) Extras {
+	return MergeExtras(Extras{Headers: s.Headers}, s.Extras, req.Extras.For(provider))
+}
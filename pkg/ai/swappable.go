@@ -0,0 +1,129 @@
+package ai
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Swappable is a stable AIProvider handle whose underlying pipeline can be
+// replaced while serving traffic. Requests stay on the pipeline they
+// started on; a replaced pipeline is closed (if it is an io.Closer) once its
+// last in-flight request, including open streams, has finished.
+type Swappable struct {
+	current atomic.Pointer[generation]
+}
+
+type generation struct {
+	provider AIProvider
+
+	mu       sync.Mutex
+	inFlight int
+	retired  bool
+	drained  chan struct{}
+}
+
+func NewSwappable(p AIProvider) *Swappable {
+	s := &Swappable{}
+	s.current.Store(&generation{provider: p, drained: make(chan struct{})})
+	return s
+}
+
+// Swap installs p for new requests. The returned channel is closed when
+// the previous pipeline has drained and been closed.
+func (s *Swappable) Swap(p AIProvider) <-chan struct{} {
+	old := s.current.Swap(&generation{provider: p, drained: make(chan struct{})})
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		<-old.drained
+		if c, ok := As[io.Closer](old.provider); ok {
+			c.Close()
+		}
+	}()
+	old.retire()
+	return closed
+}
+
+// Current returns the pipeline new requests are sent to.
+func (s *Swappable) Current() AIProvider {
+	return s.current.Load().provider
+}
+
+func (s *Swappable) Configure(cfg Config) error {
+	return s.Current().Configure(cfg)
+}
+
+func (s *Swappable) Name() string {
+	return s.Current().Name()
+}
+
+func (s *Swappable) Unwrap() AIProvider {
+	return s.Current()
+}
+
+func (s *Swappable) Generate(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	g := s.acquire()
+	defer g.release()
+	return g.provider.Generate(ctx, req)
+}
+
+func (s *Swappable) GenerateStream(ctx context.Context, req ChatRequest) (<-chan StreamResponse, error) {
+	g := s.acquire()
+	stream, err := g.provider.GenerateStream(ctx, req)
+	if err != nil || stream == nil {
+		g.release()
+		return stream, err
+	}
+
+	out := make(chan StreamResponse)
+	go func() {
+		defer g.release()
+		defer close(out)
+		for packet := range stream {
+			select {
+			case out <- packet:
+			case <-ctx.Done():
+				// The caller is gone; drain so the pipeline can retire.
+				for range stream {
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// acquire pins the current generation. A generation retired between the
+// load and the pin is skipped in favour of its successor.
+func (s *Swappable) acquire() *generation {
+	for {
+		g := s.current.Load()
+		g.mu.Lock()
+		if !g.retired {
+			g.inFlight++
+			g.mu.Unlock()
+			return g
+		}
+		g.mu.Unlock()
+	}
+}
+
+func (g *generation) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inFlight--
+	if g.retired && g.inFlight == 0 {
+		close(g.drained)
+	}
+}
+
+func (g *generation) retire() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.retired = true
+	if g.inFlight == 0 {
+		close(g.drained)
+	}
+}
@@ -0,0 +1,82 @@
+package ai_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+type gatedProvider struct {
+	name    string
+	release chan struct{}
+	closed  atomic.Bool
+}
+
+func (g *gatedProvider) Configure(cfg ai.Config) error { return nil }
+func (g *gatedProvider) Name() string                  { return g.name }
+func (g *gatedProvider) Close() error                  { g.closed.Store(true); return nil }
+func (g *gatedProvider) Generate(ctx context.Context, req ai.ChatRequest) (*ai.ChatResponse, error) {
+	if g.release != nil {
+		<-g.release
+	}
+	return &ai.ChatResponse{Content: g.name}, nil
+}
+func (g *gatedProvider) GenerateStream(ctx context.Context, req ai.ChatRequest) (<-chan ai.StreamResponse, error) {
+	ch := make(chan ai.StreamResponse)
+	go func() {
+		defer close(ch)
+		ch <- ai.StreamResponse{Chunk: g.name}
+		if g.release != nil {
+			<-g.release
+		}
+	}()
+	return ch, nil
+}
+
+func TestSwappableDrainsOldPipeline(t *testing.T) {
+	old := &gatedProvider{name: "old", release: make(chan struct{})}
+	handle := ai.NewSwappable(old)
+
+	inFlight := make(chan string)
+	go func() {
+		resp, _ := handle.Generate(context.Background(), ai.ChatRequest{})
+		inFlight <- resp.Content
+	}()
+	stream, _ := handle.GenerateStream(context.Background(), ai.ChatRequest{})
+	<-stream // first chunk arrived, stream still open
+
+	// Give the Generate goroutine time to pin the old pipeline.
+	time.Sleep(20 * time.Millisecond)
+
+	drained := handle.Swap(&gatedProvider{name: "new"})
+
+	resp, err := handle.Generate(context.Background(), ai.ChatRequest{})
+	if err != nil || resp.Content != "new" {
+		t.Fatalf("new requests should use the new pipeline, got %v %v", resp, err)
+	}
+
+	select {
+	case <-drained:
+		t.Fatal("old pipeline reported drained with requests in flight")
+	default:
+	}
+
+	close(old.release)
+	if got := <-inFlight; got != "old" {
+		t.Errorf("in-flight request should finish on old pipeline, got %s", got)
+	}
+	for range stream {
+	}
+
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("old pipeline never drained")
+	}
+	if !old.closed.Load() {
+		t.Error("drained pipeline should be closed")
+	}
+}
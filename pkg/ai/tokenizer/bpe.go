@@ -0,0 +1,121 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// DefaultPattern is an RE2-compatible approximation of the cl100k/o200k
+// pre-tokenizer (Go's regexp has no lookahead).
+const DefaultPattern = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`
+
+// BPE is a byte-level byte-pair-encoding tokenizer built from a user
+// supplied vocabulary.
+type BPE struct {
+	ranks   map[string]int
+	pattern *regexp.Regexp
+}
+
+func NewBPE(ranks map[string]int, pattern string) (*BPE, error) {
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("bpe vocabulary is empty")
+	}
+	if pattern == "" {
+		pattern = DefaultPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-tokenizer pattern: %w", err)
+	}
+	return &BPE{ranks: ranks, pattern: re}, nil
+}
+
+// LoadBPEFile reads a tiktoken vocabulary: one "<base64 token> <rank>" pair
+// per line.
+func LoadBPEFile(path, pattern string) (*BPE, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected '<token> <rank>'", path, lineNo)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid base64 token: %w", path, lineNo, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid rank: %w", path, lineNo, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewBPE(ranks, pattern)
+}
+
+func (b *BPE) CountText(text string) int {
+	total := 0
+	for _, piece := range b.pattern.FindAllString(text, -1) {
+		if _, ok := b.ranks[piece]; ok {
+			total++
+			continue
+		}
+		total += len(b.merge([]byte(piece)))
+	}
+	return total
+}
+
+func (b *BPE) CountTokens(req ai.ChatRequest) (int, error) {
+	return countRequest(req, b.CountText), nil
+}
+
+// merge applies the lowest-ranked adjacent merge until none is left.
+func (b *BPE) merge(piece []byte) []string {
+	parts := make([]string, len(piece))
+	for i := range piece {
+		parts[i] = string(piece[i : i+1])
+	}
+
+	for len(parts) > 1 {
+		bestIdx := -1
+		bestRank := 0
+		for i := 0; i < len(parts)-1; i++ {
+			rank, ok := b.ranks[parts[i]+parts[i+1]]
+			if ok && (bestIdx == -1 || rank < bestRank) {
+				bestIdx = i
+				bestRank = rank
+			}
+		}
+		if bestIdx == -1 {
+			break
+		}
+		parts[bestIdx] = parts[bestIdx] + parts[bestIdx+1]
+		parts = append(parts[:bestIdx+1], parts[bestIdx+2:]...)
+	}
+
+	return parts
+}
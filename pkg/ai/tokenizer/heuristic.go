@@ -0,0 +1,33 @@
+package tokenizer
+
+import (
+	"math"
+	"unicode/utf8"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// Heuristic estimates tokens from character count. It needs no vocabulary
+// and is usually within 10-20% for English prose.
+type Heuristic struct {
+	CharsPerToken float64
+}
+
+func NewHeuristic(charsPerToken float64) *Heuristic {
+	if charsPerToken <= 0 {
+		charsPerToken = 4.0
+	}
+	return &Heuristic{CharsPerToken: charsPerToken}
+}
+
+func (h *Heuristic) CountText(text string) int {
+	n := utf8.RuneCountInString(text)
+	if n == 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(n) / h.CharsPerToken))
+}
+
+func (h *Heuristic) CountTokens(req ai.ChatRequest) (int, error) {
+	return countRequest(req, h.CountText), nil
+}
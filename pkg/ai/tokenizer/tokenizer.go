@@ -0,0 +1,126 @@
+package tokenizer
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// Chat formats add a few tokens per message for role markers and a few
+// more to prime the assistant reply.
+const (
+	tokensPerMessage = 3
+	tokensPerReply   = 3
+	tokensPerImage   = 85
+)
+
+// Registry maps model family prefixes to tokenizers. Lookups use the longest
+// registered prefix of the model name, like the pricing catalog.
+type Registry struct {
+	mu         sync.RWMutex
+	tokenizers map[string]ai.Tokenizer
+	fallback   ai.Tokenizer
+}
+
+func NewRegistry(fallback ai.Tokenizer) *Registry {
+	if fallback == nil {
+		fallback = NewHeuristic(4.0)
+	}
+	return &Registry{
+		tokenizers: make(map[string]ai.Tokenizer),
+		fallback:   fallback,
+	}
+}
+
+var Default = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry(NewHeuristic(4.0))
+	r.Register("gpt", NewHeuristic(4.0))
+	r.Register("o1", NewHeuristic(4.0))
+	r.Register("claude", NewHeuristic(3.5))
+	r.Register("gemini", NewHeuristic(4.0))
+	r.Register("llama", NewHeuristic(3.8))
+	return r
+}
+
+func (r *Registry) Register(prefix string, t ai.Tokenizer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokenizers[prefix] = t
+}
+
+// RegisterBPEFile loads a tiktoken-style vocabulary file and registers it
+// as the exact tokenizer for the given model prefix. An empty pattern
+// selects DefaultPattern.
+func (r *Registry) RegisterBPEFile(prefix, path, pattern string) error {
+	bpe, err := LoadBPEFile(path, pattern)
+	if err != nil {
+		return err
+	}
+	r.Register(prefix, bpe)
+	return nil
+}
+
+func (r *Registry) ForModel(model string) ai.Tokenizer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if t, ok := r.tokenizers[model]; ok {
+		return t
+	}
+
+	var best ai.Tokenizer
+	var maxLen int
+	for key, t := range r.tokenizers {
+		if strings.HasPrefix(model, key) && len(key) > maxLen {
+			maxLen = len(key)
+			best = t
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return r.fallback
+}
+
+func Register(prefix string, t ai.Tokenizer) {
+	Default.Register(prefix, t)
+}
+
+func RegisterBPEFile(prefix, path, pattern string) error {
+	return Default.RegisterBPEFile(prefix, path, pattern)
+}
+
+func ForModel(model string) ai.Tokenizer {
+	return Default.ForModel(model)
+}
+
+// Count prefers the provider's remote TokenCounter when one is reachable
+// through the pipeline and falls back to the local tokenizer for req.Model.
+func Count(ctx context.Context, p ai.AIProvider, req ai.ChatRequest) (int, error) {
+	if counter, ok := ai.As[ai.TokenCounter](p); ok {
+		if n, err := counter.CountTokens(ctx, req); err == nil {
+			return n, nil
+		}
+	}
+	return ForModel(req.Model).CountTokens(req)
+}
+
+func countRequest(req ai.ChatRequest, countText func(string) int) int {
+	total := tokensPerReply
+	for _, msg := range req.Messages {
+		total += tokensPerMessage + countText(msg.Role)
+		for _, part := range msg.Content {
+			switch part.Type {
+			case "text":
+				total += countText(part.Text)
+			case "image_url":
+				total += tokensPerImage
+			}
+		}
+	}
+	return total
+}
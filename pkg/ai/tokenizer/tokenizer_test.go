@@ -0,0 +1,112 @@
+package tokenizer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/mock"
+)
+
+func textRequest(model, text string) ai.ChatRequest {
+	return ai.ChatRequest{
+		Model: model,
+		Messages: []ai.ChatMessage{
+			{Role: "user", Content: []ai.Content{{Type: "text", Text: text}}},
+		},
+	}
+}
+
+func TestHeuristicCount(t *testing.T) {
+	h := NewHeuristic(4.0)
+	n, err := h.CountTokens(textRequest("", "abcdefgh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 2 text + 1 role + 3 per message + 3 reply priming
+	if n != 9 {
+		t.Errorf("expected 9 tokens, got %d", n)
+	}
+}
+
+func TestRegistryLongestPrefix(t *testing.T) {
+	r := NewRegistry(nil)
+	gpt := NewHeuristic(4.0)
+	gpt4o := NewHeuristic(2.0)
+	r.Register("gpt", gpt)
+	r.Register("gpt-4o", gpt4o)
+
+	if r.ForModel("gpt-4o-mini") != gpt4o {
+		t.Error("expected gpt-4o tokenizer for gpt-4o-mini")
+	}
+	if r.ForModel("gpt-3.5-turbo") != gpt {
+		t.Error("expected gpt tokenizer for gpt-3.5-turbo")
+	}
+	if r.ForModel("unknown") != r.fallback {
+		t.Error("expected fallback tokenizer for unknown model")
+	}
+}
+
+func TestBPEFile(t *testing.T) {
+	vocab := []string{"h", "e", "l", "o", " ", "he", "ll", "hell", "hello"}
+	path := filepath.Join(t.TempDir(), "vocab.tiktoken")
+
+	var data string
+	for rank, tok := range vocab {
+		data += fmt.Sprintf("%s %d\n", base64.StdEncoding.EncodeToString([]byte(tok)), rank)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	bpe, err := LoadBPEFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadBPEFile failed: %v", err)
+	}
+
+	if n := bpe.CountText("hello"); n != 1 {
+		t.Errorf("expected 'hello' to be 1 token, got %d", n)
+	}
+	if n := bpe.CountText("hell"); n != 1 {
+		t.Errorf("expected 'hell' to be 1 token, got %d", n)
+	}
+	// " hello" is a single pre-token that is not in the vocabulary
+	if n := bpe.CountText("hello hello"); n != 3 {
+		t.Errorf("expected 3 tokens, got %d", n)
+	}
+}
+
+type countingProvider struct {
+	*mock.MockClient
+}
+
+func (c *countingProvider) CountTokens(ctx context.Context, req ai.ChatRequest) (int, error) {
+	return 42, nil
+}
+
+type wrapper struct {
+	ai.AIProvider
+}
+
+func (w *wrapper) Unwrap() ai.AIProvider { return w.AIProvider }
+
+func TestCountPrefersRemoteCounter(t *testing.T) {
+	p := &wrapper{&countingProvider{mock.NewClient("", false)}}
+
+	n, err := Count(context.Background(), p, textRequest("gpt-4o", "hi"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 42 {
+		t.Errorf("expected remote count 42, got %d", n)
+	}
+
+	n, _ = Count(context.Background(), mock.NewClient("", false), textRequest("gpt-4o", "hi"))
+	if n == 42 {
+		t.Error("expected local count for provider without TokenCounter")
+	}
+}
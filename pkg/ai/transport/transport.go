@@ -0,0 +1,180 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Config describes the HTTP transport shared by every provider client.
+// The zero value behaves like http.DefaultTransport.
+type Config struct {
+	// ProxyURL routes all traffic through an egress proxy. When empty the
+	// standard HTTP_PROXY/HTTPS_PROXY/NO_PROXY variables apply.
+	ProxyURL string `yaml:"proxy_url"`
+
+	// CABundle is a PEM file appended to the system roots.
+	CABundle string `yaml:"ca_bundle"`
+	// ClientCert and ClientKey enable mutual TLS.
+	ClientCert         string `yaml:"client_cert"`
+	ClientKey          string `yaml:"client_key"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+
+	MaxIdleConns        int           `yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int           `yaml:"max_conns_per_host"`
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout"`
+	DisableHTTP2        bool          `yaml:"disable_http2"`
+
+	// Timeout is the overall request timeout of the http.Client.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Middleware decorates a RoundTripper, e.g. for auditing or request
+// signing.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// New builds a RoundTripper from cfg and wraps it with chain. The first
+// middleware is the outermost one.
+func New(cfg Config, chain ...Middleware) (http.RoundTripper, error) {
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		u, err := url.Parse(cfg.ProxyURL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy_url %q", cfg.ProxyURL)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	t := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if cfg.MaxIdleConns > 0 {
+		t.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.DisableHTTP2 {
+		// A non-nil empty map turns off the automatic HTTP/2 upgrade.
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	var rt http.RoundTripper = t
+	for i := len(chain) - 1; i >= 0; i-- {
+		rt = chain[i](rt)
+	}
+	return rt, nil
+}
+
+func NewHTTPClient(cfg Config, chain ...Middleware) (*http.Client, error) {
+	rt, err := New(cfg, chain...)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: rt, Timeout: cfg.Timeout}, nil
+}
+
+// Merge returns base with every non-zero field of override applied.
+func Merge(base, override Config) Config {
+	out := base
+	if override.ProxyURL != "" {
+		out.ProxyURL = override.ProxyURL
+	}
+	if override.CABundle != "" {
+		out.CABundle = override.CABundle
+	}
+	if override.ClientCert != "" {
+		out.ClientCert = override.ClientCert
+	}
+	if override.ClientKey != "" {
+		out.ClientKey = override.ClientKey
+	}
+	if override.InsecureSkipVerify {
+		out.InsecureSkipVerify = true
+	}
+	if override.MaxIdleConns > 0 {
+		out.MaxIdleConns = override.MaxIdleConns
+	}
+	if override.MaxIdleConnsPerHost > 0 {
+		out.MaxIdleConnsPerHost = override.MaxIdleConnsPerHost
+	}
+	if override.MaxConnsPerHost > 0 {
+		out.MaxConnsPerHost = override.MaxConnsPerHost
+	}
+	if override.IdleConnTimeout > 0 {
+		out.IdleConnTimeout = override.IdleConnTimeout
+	}
+	if override.DisableHTTP2 {
+		out.DisableHTTP2 = true
+	}
+	if override.Timeout > 0 {
+		out.Timeout = override.Timeout
+	}
+	return out
+}
+
+func (cfg Config) tlsConfig() (*tls.Config, error) {
+	tc := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CABundle != "" {
+		pem, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("read ca_bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca_bundle %s contains no PEM certificates", cfg.CABundle)
+		}
+		tc.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return nil, errors.New("client_cert and client_key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tc.Certificates = []tls.Certificate{cert}
+	}
+
+	return tc, nil
+}
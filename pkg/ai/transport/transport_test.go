@@ -0,0 +1,68 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProxyAndChain(t *testing.T) {
+	var gotURL, gotHeader string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		gotHeader = r.Header.Get("X-Audit")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				r.Header.Set("X-Audit", strings.Join(order, ","))
+				return next.RoundTrip(r)
+			})
+		}
+	}
+
+	client, err := NewHTTPClient(Config{ProxyURL: proxy.URL}, tag("outer"), tag("inner"))
+	if err != nil {
+		t.Fatalf("NewHTTPClient failed: %v", err)
+	}
+
+	resp, err := client.Get("http://api.example.invalid/v1/models")
+	if err != nil {
+		t.Fatalf("request through proxy failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if gotURL != "http://api.example.invalid/v1/models" {
+		t.Errorf("request did not go through the proxy, got %q", gotURL)
+	}
+	if gotHeader != "outer,inner" {
+		t.Errorf("middleware chain order wrong: %q", gotHeader)
+	}
+}
+
+func TestInvalidTLSSettings(t *testing.T) {
+	cases := []Config{
+		{CABundle: "/does/not/exist.pem"},
+		{ClientCert: "/tmp/cert.pem"},
+		{ProxyURL: "::not a url"},
+	}
+	for _, cfg := range cases {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := Config{ProxyURL: "http://a:1", MaxIdleConns: 10}
+	got := Merge(base, Config{MaxIdleConns: 50, DisableHTTP2: true})
+	if got.ProxyURL != "http://a:1" || got.MaxIdleConns != 50 || !got.DisableHTTP2 {
+		t.Errorf("unexpected merge result: %+v", got)
+	}
+}
@@ -6,12 +6,18 @@ import (
 )
 
 type Config struct {
-	APIKey      string
-	BaseURL     string
-	ModelName   string
-	MaxTokens   int
-	Temperature float64
-	Timeout     time.Duration
+	APIKey    string
+	BaseURL   string
+	ModelName string
+	MaxTokens int
+	Timeout   time.Duration
+
+	// Temperature is the client default; nil leaves it to the provider and
+	// Float64(0) asks for deterministic output.
+	Temperature *float64
+
+	// Extras are merged into every request this client sends.
+	Extras Extras
 }
 
 type ModelType string
@@ -29,12 +35,39 @@ var (
 )
 
 type ChatRequest struct {
-	Model       string        `json:"model"`
-	Messages    []ChatMessage `json:"messages"`
-	Temperature float64       `json:"temperature"`
-	MaxTokens   int           `json:"max_tokens,omitempty"`
-	Stream      bool          `json:"stream,omitempty"`
-	JSONMode    bool          `json:"json_mode,omitempty"`
+	Model     string        `json:"model"`
+	Messages  []ChatMessage `json:"messages"`
+	MaxTokens int           `json:"max_tokens,omitempty"`
+	Stream    bool          `json:"stream,omitempty"`
+	JSONMode  bool          `json:"json_mode,omitempty"`
+
+	// Sampling parameters are pointers so an explicit zero (e.g. temperature
+	// 0 for deterministic runs) is distinguishable from unset. Use Float64,
+	// Int and Int64 to build them inline.
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	TopK             *int     `json:"top_k,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	Seed             *int64   `json:"seed,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+
+	// N asks for several candidates. Providers without native support are
+	// called N times and the results merged.
+	N           int  `json:"n,omitempty"`
+	Logprobs    bool `json:"logprobs,omitempty"`
+	TopLogprobs int  `json:"top_logprobs,omitempty"`
+
+	Prompt *PromptRef `json:"prompt,omitempty"`
+
+	Extras ProviderExtras `json:"extras,omitempty"`
+}
+
+// PromptRef identifies the registered prompt template a request was
+// rendered from.
+type PromptRef struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
 }
 
 type ChatMessage struct {
@@ -52,6 +85,55 @@ type ChatResponse struct {
 	Content string     `json:"content"`
 	Usage   TokenUsage `json:"usage"`
 	Cached  bool       `json:"cached"`
+
+	// ID is the provider's response identifier and Model the model version
+	// that actually served the request.
+	ID           string         `json:"id,omitempty"`
+	Model        string         `json:"model,omitempty"`
+	FinishReason FinishReason   `json:"finish_reason,omitempty"`
+	Safety       []SafetyRating `json:"safety,omitempty"`
+	Choices      []Choice       `json:"choices,omitempty"`
+
+	// Unsupported lists request parameters the provider could not honor
+	// and therefore ignored.
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+type FinishReason string
+
+const (
+	FinishStop          FinishReason = "stop"
+	FinishLength        FinishReason = "length"
+	FinishContentFilter FinishReason = "content_filter"
+	FinishToolCalls     FinishReason = "tool_calls"
+	FinishOther         FinishReason = "other"
+)
+
+// Choice is one candidate completion. ChatResponse.Content mirrors
+// Choices[0].Content.
+type Choice struct {
+	Index        int            `json:"index"`
+	Content      string         `json:"content"`
+	FinishReason FinishReason   `json:"finish_reason,omitempty"`
+	Safety       []SafetyRating `json:"safety,omitempty"`
+	Logprobs     []TokenLogprob `json:"logprobs,omitempty"`
+}
+
+type SafetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability,omitempty"`
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+type TokenLogprob struct {
+	Token       string       `json:"token"`
+	Logprob     float64      `json:"logprob"`
+	TopLogprobs []TopLogprob `json:"top_logprobs,omitempty"`
+}
+
+type TopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
 }
 
 type TokenUsage struct {
@@ -59,4 +141,9 @@ type TokenUsage struct {
 	OutputTokens int     `json:"completion_tokens"`
 	TotalTokens  int     `json:"total_tokens"`
 	CostUSD      float64 `json:"cost_usd,omitempty"`
+
+	// Model is the model the cost stage priced, and ModelAlias the alias it
+	// was requested as, if any.
+	Model      string `json:"model,omitempty"`
+	ModelAlias string `json:"model_alias,omitempty"`
 }
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// interpolate expands environment variables in every scalar of the
+// document, and secret references in the fields typed Secret (api_key and
+// header values), so a resolved secret is always redacted when printed.
+// Working on nodes rather than raw text means an env value can never inject
+// YAML structure, and errors keep their lines.
+func interpolate(ctx context.Context, n *yaml.Node) error {
+	in := interpolator{ctx: ctx, expanded: map[*yaml.Node]bool{}, resolved: map[*yaml.Node]bool{}}
+	return in.walk(n, nil)
+}
+
+// interpolator follows aliases, so a secret field reached only through an
+// anchor or a "<<" merge is still resolved; each node is expanded once.
+type interpolator struct {
+	ctx      context.Context
+	expanded map[*yaml.Node]bool
+	resolved map[*yaml.Node]bool
+}
+
+func (in *interpolator) walk(n *yaml.Node, path []string) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if n.Tag == "!!binary" {
+			return nil
+		}
+		changed := false
+		if !in.expanded[n] {
+			in.expanded[n] = true
+			v, err := expandEnv(n.Value)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n.Line, err)
+			}
+			changed = v != n.Value
+			n.Value = v
+		}
+		if secretPath(path) && !in.resolved[n] {
+			in.resolved[n] = true
+			v, resolved, err := resolveRef(in.ctx, n.Value)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n.Line, err)
+			}
+			changed = changed || resolved
+			n.Value = v
+		}
+		if changed {
+			// Re-resolve the tag so "${PORT:-8080}" can still fill an int.
+			n.Tag = ""
+		}
+
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if err := in.walk(key, nil); err != nil {
+				return err
+			}
+			sub := path
+			if key.Value != "<<" {
+				sub = append(path[:len(path):len(path)], key.Value)
+			}
+			if err := in.walk(value, sub); err != nil {
+				return err
+			}
+		}
+
+	case yaml.AliasNode:
+		return in.walk(n.Alias, path)
+
+	default:
+		for _, child := range n.Content {
+			if err := in.walk(child, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// secretPath reports whether path names a Secret field:
+// providers.<name>.api_key or providers.<name>.headers.<header>.
+func secretPath(path []string) bool {
+	if len(path) < 3 || path[0] != "providers" {
+		return false
+	}
+	return (len(path) == 3 && path[2] == "api_key") || (len(path) == 4 && path[2] == "headers")
+}
+
+// expandEnv replaces ${NAME} and ${NAME:-default}. "$${" escapes a literal
+// "${". An unset variable without a default is an error, so typos do not
+// silently become empty API keys.
+func expandEnv(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i == -1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1])
+			b.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], '}')
+		if end == -1 {
+			return "", fmt.Errorf("unterminated ${ in %q", s)
+		}
+		b.WriteString(s[:i])
+
+		expr := s[i+2 : i+end]
+		name, def, hasDef := strings.Cut(expr, ":-")
+		if name == "" {
+			return "", fmt.Errorf("empty variable name in ${%s}", expr)
+		}
+		v, ok := os.LookupEnv(name)
+		switch {
+		case ok && (v != "" || !hasDef):
+			b.WriteString(v)
+		case hasDef:
+			b.WriteString(def)
+		default:
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		s = s[i+end+1:]
+	}
+}
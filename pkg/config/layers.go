@@ -0,0 +1,176 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EnvPrefix marks environment overrides. Nested keys are separated by a
+	// double underscore: GOPOLY_PROVIDERS__OPENAI__MODEL=gpt-4o-mini.
+	EnvPrefix = "GOPOLY_"
+
+	// ProfileEnv selects the profile when none is passed explicitly.
+	ProfileEnv = "GOPOLY_PROFILE"
+)
+
+// Load builds the configuration in layers: the base file at path, then the
+// profile file next to it (config.yaml + "prod" -> config.prod.yaml), then
+// GOPOLY_* environment overrides. Mappings merge key by key; lists and
+// scalars from a later layer replace earlier ones. The result is
+// interpolated and validated; see Check for the diagnostics.
+func Load(path, profile string) (*Config, error) {
+	cfg, problems, err := Check(path, profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := errorsOf(problems); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+	return cfg, nil
+}
+
+// layered reads and merges the layers of path, remembering where every
+// node came from.
+func layered(path, profile string) (*document, error) {
+	d := &document{origin: make(map[*yaml.Node]string)}
+
+	root, err := readNode(path)
+	if err != nil {
+		return nil, err
+	}
+	d.track(root, path)
+	d.root = root
+
+	if profile != "" {
+		overlayPath := ProfilePath(path, profile)
+		overlay, err := readNode(overlayPath)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", profile, err)
+		}
+		d.track(overlay, overlayPath)
+		mergeNodes(root, overlay)
+	}
+
+	if err := applyEnv(root, os.Environ(), d.track); err != nil {
+		return nil, err
+	}
+
+	if err := interpolate(context.Background(), root); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+// ProfilePath returns the overlay file for profile, e.g. config.dev.yaml.
+func ProfilePath(path, profile string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + profile + ext
+}
+
+// readNode returns the root mapping of the YAML file at path.
+func readNode(path string) (*yaml.Node, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(file, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: top level must be a mapping", path)
+	}
+	return root, nil
+}
+
+// mergeNodes merges the overlay mapping into base. Replaced values are
+// swapped in rather than copied, so their origin is kept.
+func mergeNodes(base, overlay *yaml.Node) {
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		j := index(base, key.Value)
+		switch {
+		case j < 0:
+			base.Content = append(base.Content, key, value)
+		case base.Content[j].Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeNodes(base.Content[j], value)
+		default:
+			base.Content[j] = value
+		}
+	}
+}
+
+// applyEnv sets GOPOLY_* overrides, creating intermediate mappings as
+// needed. Values are parsed like plain YAML scalars, so numbers and
+// durations work as usual. Every node created is passed to track with the
+// variable's name.
+func applyEnv(root *yaml.Node, environ []string, track func(n *yaml.Node, origin string)) error {
+	sort.Strings(environ)
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, EnvPrefix) || name == ProfileEnv {
+			continue
+		}
+
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)), "__")
+		node := root
+		for _, key := range path[:len(path)-1] {
+			next := lookup(node, key)
+			if next == nil {
+				next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				k := scalar(key)
+				track(k, name)
+				track(next, name)
+				node.Content = append(node.Content, k, next)
+			}
+			if next.Kind != yaml.MappingNode {
+				return fmt.Errorf("%s: %s is not a mapping", name, key)
+			}
+			node = next
+		}
+
+		last, v := path[len(path)-1], scalar(value)
+		track(v, name)
+		if j := index(node, last); j >= 0 {
+			node.Content[j] = v
+		} else {
+			k := scalar(last)
+			track(k, name)
+			node.Content = append(node.Content, k, v)
+		}
+	}
+	return nil
+}
+
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	if j := index(mapping, key); j >= 0 {
+		return mapping.Content[j]
+	}
+	return nil
+}
+
+// index returns the position of key's value in mapping, or -1.
+func index(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func scalar(v string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: v}
+}
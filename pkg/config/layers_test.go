@@ -0,0 +1,96 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+func TestLoadLayersProfileAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "config.yaml", `
+app:
+  timeout: 45
+active_provider: openai
+providers:
+  openai:
+    api_key: sk-base
+    model: gpt-4o
+    temperature: 0.7
+  ollama:
+    model: llama3
+`)
+	writeFile(t, dir, "config.prod.yaml", `
+providers:
+  openai:
+    model: gpt-4o-mini
+    max_tokens: 512
+`)
+	t.Setenv("GOPOLY_PROVIDERS__OPENAI__TEMPERATURE", "0")
+	t.Setenv("GOPOLY_PROVIDERS__OLLAMA__TIMEOUT", "2m")
+
+	cfg, err := Load(base, "prod")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	openai := cfg.Providers["openai"]
+	if openai.Model != "gpt-4o-mini" || openai.MaxTokens != 512 || openai.APIKey.Value() != "sk-base" {
+		t.Errorf("profile not layered over base: %+v", openai)
+	}
+	if openai.Temperature == nil || *openai.Temperature != 0 {
+		t.Errorf("env override of temperature to explicit 0 lost: %v", openai.Temperature)
+	}
+
+	if got := cfg.AIConfig("openai").Timeout; got != 45*time.Second {
+		t.Errorf("app.timeout should flow into ai.Config, got %v", got)
+	}
+	if got := cfg.AIConfig("ollama").Timeout; got != 2*time.Minute {
+		t.Errorf("provider timeout should win, got %v", got)
+	}
+
+	if _, err := Load(base, "staging"); err == nil {
+		t.Error("missing profile file should be an error")
+	}
+}
+
+func TestProviderSettingsReachTheWire(t *testing.T) {
+	var body map[string]interface{}
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Team")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	temp := 0.0
+	cfg := &Config{Providers: map[string]Provider{"openai": {
+		APIKey:      "sk",
+		BaseURL:     server.URL,
+		Temperature: &temp,
+		MaxTokens:   256,
+		Headers:     map[string]Secret{"X-Team": "search"},
+		Extra:       map[string]interface{}{"user": "svc-search"},
+	}}}
+
+	p, err := cfg.NewProvider("openai")
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	if _, err := p.Generate(context.Background(), ai.ChatRequest{}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if body["temperature"] != 0.0 || body["max_tokens"] != 256.0 || body["user"] != "svc-search" {
+		t.Errorf("provider defaults not applied: %v", body)
+	}
+	if header != "search" {
+		t.Errorf("provider header not sent: %q", header)
+	}
+}
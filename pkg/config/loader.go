@@ -1,17 +1,44 @@
 package config
 
 import (
-	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"sort"
+	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	_ "github.com/ahmettasdemir/gopolyai/pkg/ai/providers"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/transport"
 )
 
 type Config struct {
 	App            AppConfig           `yaml:"app"`
 	ActiveProvider string              `yaml:"active_provider"`
+	Transport      transport.Config    `yaml:"transport"`
 	Providers      map[string]Provider `yaml:"providers"`
+
+	// Pipeline is the default middleware chain for providers that do not
+	// declare their own.
+	Pipeline []Stage `yaml:"pipeline"`
+
+	Auto AutoConfig `yaml:"auto"`
+
+	// Aliases map logical model names such as "fast" to a provider and
+	// model. They extend, and override, aliases registered in code.
+	Aliases ai.Aliases `yaml:"aliases"`
+}
+
+// AutoProvider as active_provider routes across every configured provider
+// with credentials.
+const AutoProvider = "auto"
+
+type AutoConfig struct {
+	// Preference orders the providers; unlisted ones follow alphabetically.
+	Preference []string `yaml:"preference"`
+
+	HealthInterval time.Duration `yaml:"health_interval"`
+	HealthTimeout  time.Duration `yaml:"health_timeout"`
 }
 
 type AppConfig struct {
@@ -19,43 +46,152 @@ type AppConfig struct {
 }
 
 type Provider struct {
-	APIKey  string `yaml:"api_key"`
+	// Type selects the registered provider implementation and defaults to
+	// the entry's name, so an OpenAI-compatible vendor can be declared as
+	// `groq: {type: openai, base_url: ...}`.
+	Type    string `yaml:"type"`
+	APIKey  Secret `yaml:"api_key"`
 	Model   string `yaml:"model"`
 	BaseURL string `yaml:"base_url"`
+
+	// Transport overrides individual fields of the global transport.
+	Transport *transport.Config `yaml:"transport"`
+
+	// Pipeline replaces the top-level pipeline for this provider; an empty
+	// list disables middleware entirely.
+	Pipeline []Stage `yaml:"pipeline"`
+
+	// Timeout overrides the transport and app timeouts for this provider.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// Temperature and MaxTokens apply to requests that leave them unset.
+	Temperature *float64 `yaml:"temperature"`
+	MaxTokens   int      `yaml:"max_tokens"`
+
+	Headers map[string]Secret `yaml:"headers"`
+
+	// Extra is deep-merged into every request body, for vendor options
+	// gopolyai does not model.
+	Extra map[string]interface{} `yaml:"extra"`
 }
 
+// LoadConfig loads path with the profile named by GOPOLY_PROFILE, if any.
+// See Load.
 func LoadConfig(path string) (*Config, error) {
-	file, err := os.ReadFile(path)
+	return Load(path, os.Getenv(ProfileEnv))
+}
 
-	if err != nil {
-		return nil, err
+// AutoCandidates lists the providers "auto" routes across, in preference
+// order.
+func (c *Config) AutoCandidates() []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if p, ok := c.Providers[name]; ok && !seen[name] && p.hasCredentials(name) {
+			names = append(names, name)
+		}
+		seen[name] = true
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(file, &cfg); err != nil {
-		return nil, err
+	for _, name := range c.Auto.Preference {
+		add(name)
+	}
+	rest := make([]string, 0, len(c.Providers))
+	for name := range c.Providers {
+		rest = append(rest, name)
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		add(name)
 	}
+	return names
+}
 
-	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("configuration validation failed: %w", err)
+// ModelAliases returns the aliases registered with ai.RegisterAlias,
+// overridden by the config's own.
+func (c *Config) ModelAliases() ai.Aliases {
+	aliases := ai.DefaultRegistry.Aliases()
+	for name, a := range c.Aliases {
+		aliases[name] = a
 	}
+	return aliases
+}
 
-	return &cfg, nil
+// hasCredentials reports whether the provider can authenticate; local
+// Ollama needs no key.
+func (p Provider) hasCredentials(name string) bool {
+	return p.APIKey != "" || p.Type == ai.ProviderOllama || (p.Type == "" && name == ai.ProviderOllama)
 }
 
-func (c *Config) Validate() error {
-	if c.ActiveProvider == "" {
-		return errors.New("active_provider is required in config.yaml")
+// Options implements ai.ProviderSpec, so a Provider can be passed straight
+// to ai.New.
+func (p Provider) Options() []ai.Option {
+	return p.aiConfig().Options()
+}
+
+func (p Provider) aiConfig() ai.Config {
+	cfg := ai.Config{
+		APIKey:      p.APIKey.Value(),
+		BaseURL:     p.BaseURL,
+		ModelName:   p.Model,
+		MaxTokens:   p.MaxTokens,
+		Timeout:     p.Timeout,
+		Temperature: p.Temperature,
+	}
+	if len(p.Headers) > 0 {
+		cfg.Extras.Headers = make(map[string]string, len(p.Headers))
+		for k, v := range p.Headers {
+			cfg.Extras.Headers[k] = v.Value()
+		}
 	}
+	cfg.Extras.Body = p.Extra
+	return cfg
+}
 
-	provider, ok := c.Providers[c.ActiveProvider]
+// AIConfig returns the ai.Config for the named provider. The timeout comes
+// from the provider, then the transport section, then app.timeout.
+func (c *Config) AIConfig(name string) ai.Config {
+	cfg := c.Providers[name].aiConfig()
+	if cfg.Timeout == 0 {
+		cfg.Timeout = c.transportConfig(name).Timeout
+	}
+	if cfg.Timeout == 0 && c.App.Timeout > 0 {
+		cfg.Timeout = time.Duration(c.App.Timeout) * time.Second
+	}
+	return cfg
+}
+
+// NewProvider builds the named provider through ai.DefaultRegistry, wired to
+// the configured transport.
+func (c *Config) NewProvider(name string, chain ...transport.Middleware) (ai.AIProvider, error) {
+	p, ok := c.Providers[name]
 	if !ok {
-		return fmt.Errorf("provider '%s' not found in providers list", c.ActiveProvider)
+		return nil, fmt.Errorf("provider '%s' not found in providers list", name)
+	}
+
+	rt, err := transport.New(c.transportConfig(name), chain...)
+	if err != nil {
+		return nil, fmt.Errorf("provider '%s': %w", name, err)
 	}
 
-	if provider.APIKey == "" && c.ActiveProvider != "ollama" {
-		return fmt.Errorf("api_key is required for provider '%s'", c.ActiveProvider)
+	typ := p.Type
+	if typ == "" {
+		typ = name
 	}
+	return ai.New(typ, c.AIConfig(name), ai.WithTransport(rt))
+}
+
+// HTTPClient builds the http.Client for a provider from the global
+// transport section and the provider's override. Extra RoundTripper
+// middleware, such as audit logging, wraps the result.
+func (c *Config) HTTPClient(name string, chain ...transport.Middleware) (*http.Client, error) {
+	return transport.NewHTTPClient(c.transportConfig(name), chain...)
+}
 
-	return nil
+func (c *Config) transportConfig(name string) transport.Config {
+	tc := c.Transport
+	if p, ok := c.Providers[name]; ok && p.Transport != nil {
+		tc = transport.Merge(tc, *p.Transport)
+	}
+	return tc
 }
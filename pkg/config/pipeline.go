@@ -0,0 +1,468 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/logger"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/middleware"
+	"gopkg.in/yaml.v3"
+)
+
+// Pipeline stage names accepted in the `pipeline` section.
+const (
+	StageTracing        = "tracing"
+	StageCircuitBreaker = "circuit_breaker"
+	StageLogging        = "logging"
+	StageRetry          = "retry"
+	StageRateLimit      = "rate_limit"
+	StageCost           = "cost"
+	StageContext        = "context"
+)
+
+// Stage is one middleware in a pipeline. In YAML it is either a bare name
+// (`- tracing`) or a single-key map carrying its parameters
+// (`- retry: {max_retries: 2}`).
+type Stage struct {
+	Name   string
+	params yaml.Node
+}
+
+func (s *Stage) UnmarshalYAML(n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		s.Name = n.Value
+	case yaml.MappingNode:
+		if len(n.Content) != 2 {
+			return &nodeError{n, "a pipeline stage must have exactly one name"}
+		}
+		s.Name = n.Content[0].Value
+		s.params = *n.Content[1]
+	default:
+		return &nodeError{n, "a pipeline stage must be a name or a map"}
+	}
+	return nil
+}
+
+func (s Stage) MarshalYAML() (interface{}, error) {
+	if s.params.Kind == 0 {
+		return s.Name, nil
+	}
+	return map[string]*yaml.Node{s.Name: &s.params}, nil
+}
+
+// Decode reads the stage's parameters into v; stages without parameters
+// leave v untouched. Keys v has no field for are rejected.
+func (s Stage) Decode(v interface{}) error {
+	if s.params.Kind == 0 {
+		return nil
+	}
+	var err error
+	knownFields(&s.params, reflect.TypeOf(v), nil, func(key *yaml.Node, _ []string, msg string) {
+		if err == nil {
+			err = &nodeError{key, msg}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return s.params.Decode(v)
+}
+
+// pricing returns the price catalog of a cost stage: DefaultPricing with
+// the stage's overrides applied.
+func (s Stage) pricing() (map[string]middleware.ModelPrice, error) {
+	var params CostParams
+	if err := s.Decode(&params); err != nil {
+		return nil, err
+	}
+	if len(params.Pricing) == 0 {
+		return middleware.DefaultPricing, nil
+	}
+
+	pricing := make(map[string]middleware.ModelPrice, len(middleware.DefaultPricing)+len(params.Pricing))
+	for model, price := range middleware.DefaultPricing {
+		pricing[model] = price
+	}
+	for model, price := range params.Pricing {
+		if price.Input < 0 || price.Output < 0 {
+			return nil, fmt.Errorf("price of %q must not be negative", model)
+		}
+		pricing[model] = middleware.ModelPrice{InputPrice: price.Input, OutputPrice: price.Output}
+	}
+	return pricing, nil
+}
+
+// stageOrder is the usual position of each stage, outermost first.
+var stageOrder = []string{
+	StageTracing, StageCircuitBreaker, StageLogging, StageRetry,
+	StageRateLimit, StageCost, StageContext,
+}
+
+func stageRank(name string) int {
+	for i, n := range stageOrder {
+		if n == name {
+			return i
+		}
+	}
+	return len(stageOrder)
+}
+
+// with returns a copy of s with params merged over its own.
+func (s Stage) with(params map[string]interface{}) (Stage, error) {
+	var overlay yaml.Node
+	if err := overlay.Encode(params); err != nil {
+		return Stage{}, err
+	}
+
+	merged := s
+	if s.params.Kind == yaml.MappingNode {
+		merged.params = *cloneNode(&s.params)
+		mergeNodes(&merged.params, &overlay)
+	} else {
+		merged.params = overlay
+	}
+	return merged, nil
+}
+
+func cloneNode(n *yaml.Node) *yaml.Node {
+	cp := *n
+	cp.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		cp.Content[i] = cloneNode(child)
+	}
+	return &cp
+}
+
+// OverrideStage merges params, keyed like the YAML (e.g. "max_retries"),
+// into the named stage of the top-level pipeline and of every provider
+// pipeline. Where the stage is missing it is added in its usual position,
+// so command-line flags win over the file.
+func (c *Config) OverrideStage(name string, params map[string]interface{}) error {
+	override := func(stages []Stage) ([]Stage, error) {
+		for i, s := range stages {
+			if s.Name == name {
+				merged, err := s.with(params)
+				if err != nil {
+					return nil, err
+				}
+				out := append([]Stage(nil), stages...)
+				out[i] = merged
+				return out, nil
+			}
+		}
+
+		added, err := Stage{Name: name}.with(params)
+		if err != nil {
+			return nil, err
+		}
+		at := len(stages)
+		for i, s := range stages {
+			if stageRank(s.Name) > stageRank(name) {
+				at = i
+				break
+			}
+		}
+		out := append([]Stage(nil), stages[:at]...)
+		out = append(out, added)
+		return append(out, stages[at:]...), nil
+	}
+
+	var err error
+	if c.Pipeline, err = override(c.Pipeline); err != nil {
+		return err
+	}
+	for name, p := range c.Providers {
+		if p.Pipeline == nil {
+			continue
+		}
+		if p.Pipeline, err = override(p.Pipeline); err != nil {
+			return err
+		}
+		c.Providers[name] = p
+	}
+	return nil
+}
+
+// RemoveStage drops the named stage from the top-level pipeline and from
+// every provider pipeline.
+func (c *Config) RemoveStage(name string) {
+	remove := func(stages []Stage) []Stage {
+		out := make([]Stage, 0, len(stages))
+		for _, s := range stages {
+			if s.Name != name {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+
+	if c.Pipeline != nil {
+		c.Pipeline = remove(c.Pipeline)
+	}
+	for n, p := range c.Providers {
+		if p.Pipeline == nil {
+			continue
+		}
+		p.Pipeline = remove(p.Pipeline)
+		c.Providers[n] = p
+	}
+}
+
+// Pricing returns the price catalog of the named provider's cost stage,
+// and false if its pipeline has no cost stage.
+func (c *Config) Pricing(name string) (map[string]middleware.ModelPrice, bool) {
+	stages := c.Providers[name].Pipeline
+	if stages == nil {
+		stages = c.Pipeline
+	}
+	for _, s := range stages {
+		if s.Name != StageCost {
+			continue
+		}
+		if pricing, err := s.pricing(); err == nil {
+			return pricing, true
+		}
+	}
+	return nil, false
+}
+
+// RetryParams configures the retry stage. MaxRetries is a pointer so that an
+// explicit 0 turns retries off instead of selecting the default of 3.
+type RetryParams struct {
+	MaxRetries *int          `yaml:"max_retries"`
+	BaseDelay  time.Duration `yaml:"base_delay"`
+	MaxDelay   time.Duration `yaml:"max_delay"`
+}
+
+type CircuitBreakerParams struct {
+	Threshold    int           `yaml:"threshold"`
+	ResetTimeout time.Duration `yaml:"reset_timeout"`
+}
+
+type RateLimitParams struct {
+	RPS   int `yaml:"rps"`
+	Burst int `yaml:"burst"`
+}
+
+type LoggingParams struct {
+	Payloads   bool `yaml:"payloads"`
+	ErrorsOnly bool `yaml:"errors_only"`
+}
+
+// CostParams adds or overrides prices (USD per million tokens) on top of
+// middleware.DefaultPricing.
+type CostParams struct {
+	Pricing map[string]struct {
+		Input  float64 `yaml:"input"`
+		Output float64 `yaml:"output"`
+	} `yaml:"pricing"`
+}
+
+type ContextParams struct {
+	Strategy string `yaml:"strategy"` // drop_oldest (default) or keep_last
+	KeepLast int    `yaml:"keep_last"`
+}
+
+// BuildProvider builds the named provider (the active one if name is empty)
+// and wraps it in its pipeline, falling back to the top-level pipeline.
+// Logging stages write to l, or JSON on stderr if l is nil. With an empty
+// name and model aliases defined, the result is an *ai.AliasRouter serving
+// each alias from its provider's pipeline.
+func BuildProvider(cfg *Config, name string, l logger.Logger) (ai.AIProvider, error) {
+	if l == nil {
+		l = logger.NewJSONLogger(os.Stderr)
+	}
+	if name == "" {
+		name = cfg.ActiveProvider
+		if name == "" {
+			return nil, errors.New("no provider given and active_provider is not set")
+		}
+		if aliases := cfg.ModelAliases(); len(aliases) > 0 {
+			return buildAliased(cfg, name, aliases, l)
+		}
+	}
+	if name == AutoProvider {
+		return buildAuto(cfg, l)
+	}
+
+	base, err := cfg.NewProvider(name)
+	if err != nil {
+		return nil, err
+	}
+
+	stages := cfg.Providers[name].Pipeline
+	if stages == nil {
+		stages = cfg.Pipeline
+	}
+	return BuildPipeline(base, stages, l)
+}
+
+// buildAliased builds the default provider plus one pipeline per provider an
+// alias points at. Aliases to providers missing from the config fail when
+// used, since aliases registered in code may target any provider.
+func buildAliased(cfg *Config, name string, aliases ai.Aliases, l logger.Logger) (ai.AIProvider, error) {
+	def, err := BuildProvider(cfg, name, l)
+	if err != nil {
+		return nil, err
+	}
+
+	members := map[string]ai.AIProvider{name: def}
+	for alias, a := range aliases {
+		if _, ok := members[a.Provider]; ok || a.Provider == "" {
+			continue
+		}
+		if _, ok := cfg.Providers[a.Provider]; !ok && a.Provider != AutoProvider {
+			continue
+		}
+		p, err := BuildProvider(cfg, a.Provider, l)
+		if err != nil {
+			// An auto member may already be running health probers.
+			for _, m := range members {
+				if c, ok := ai.As[io.Closer](m); ok {
+					c.Close()
+				}
+			}
+			return nil, fmt.Errorf("model alias %q: %w", alias, err)
+		}
+		members[a.Provider] = p
+	}
+	return ai.NewAliasRouter(def, aliases, members), nil
+}
+
+// buildAuto routes across every provider with credentials. Each member's
+// pipeline is wrapped in a running HealthProber, so failing providers are
+// skipped (and their circuit breakers opened) before users hit errors.
+// Close the returned *ai.Router to stop the probers.
+func buildAuto(cfg *Config, l logger.Logger) (ai.AIProvider, error) {
+	var probers []*middleware.HealthProber
+	for _, name := range cfg.AutoCandidates() {
+		p, err := BuildProvider(cfg, name, l)
+		if err != nil {
+			return nil, fmt.Errorf("auto: %w", err)
+		}
+		probers = append(probers, middleware.NewHealthProber(p, cfg.Auto.HealthInterval, cfg.Auto.HealthTimeout))
+	}
+	if len(probers) == 0 {
+		return nil, errors.New("auto: no provider with credentials configured")
+	}
+
+	members := make([]ai.AIProvider, len(probers))
+	for i, hp := range probers {
+		hp.Start(context.Background())
+		members[i] = hp
+	}
+	return ai.NewRouter(members...), nil
+}
+
+// BuildPipeline wraps p in stages; the first stage is the outermost.
+func BuildPipeline(p ai.AIProvider, stages []Stage, l logger.Logger) (ai.AIProvider, error) {
+	for i := len(stages) - 1; i >= 0; i-- {
+		next, err := stages[i].wrap(p, l)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline stage %d (%s): %w", i+1, stages[i].Name, err)
+		}
+		p = next
+	}
+	return p, nil
+}
+
+func (s Stage) wrap(p ai.AIProvider, l logger.Logger) (ai.AIProvider, error) {
+	switch s.Name {
+	case StageTracing:
+		if err := s.Decode(&struct{}{}); err != nil {
+			return nil, err
+		}
+		return middleware.NewTracingMiddleware(p), nil
+
+	case StageCircuitBreaker:
+		params := CircuitBreakerParams{Threshold: 5, ResetTimeout: 30 * time.Second}
+		if err := s.Decode(&params); err != nil {
+			return nil, err
+		}
+		if params.Threshold <= 0 || params.ResetTimeout <= 0 {
+			return nil, errors.New("threshold and reset_timeout must be positive")
+		}
+		return middleware.NewCircuitBreaker(p, params.Threshold, params.ResetTimeout), nil
+
+	case StageLogging:
+		var params LoggingParams
+		if err := s.Decode(&params); err != nil {
+			return nil, err
+		}
+		return middleware.NewLoggingMiddleware(p, l, logger.Config{
+			LogPayloads:   params.Payloads,
+			LogErrorsOnly: params.ErrorsOnly,
+		}), nil
+
+	case StageRetry:
+		var params RetryParams
+		if err := s.Decode(&params); err != nil {
+			return nil, err
+		}
+		if (params.MaxRetries != nil && *params.MaxRetries < 0) || params.BaseDelay < 0 || params.MaxDelay < 0 {
+			return nil, errors.New("retry parameters must not be negative")
+		}
+		retries := middleware.DefaultMaxRetries
+		if params.MaxRetries != nil {
+			if *params.MaxRetries == 0 {
+				return p, nil
+			}
+			retries = *params.MaxRetries
+		}
+		return middleware.NewResilientClient(p, middleware.RetryConfig{
+			MaxRetries: retries,
+			BaseDelay:  params.BaseDelay,
+			MaxDelay:   params.MaxDelay,
+		}), nil
+
+	case StageRateLimit:
+		var params RateLimitParams
+		if err := s.Decode(&params); err != nil {
+			return nil, err
+		}
+		if params.RPS <= 0 {
+			return nil, errors.New("rps must be positive")
+		}
+		if params.Burst <= 0 {
+			params.Burst = params.RPS
+		}
+		return middleware.NewRateLimiterMiddleware(p, params.RPS, params.Burst), nil
+
+	case StageCost:
+		pricing, err := s.pricing()
+		if err != nil {
+			return nil, err
+		}
+		ce := middleware.NewCostEstimator(p)
+		ce.SetPricing(pricing)
+		return ce, nil
+
+	case StageContext:
+		var params ContextParams
+		if err := s.Decode(&params); err != nil {
+			return nil, err
+		}
+		var strategy middleware.TrimStrategy
+		switch params.Strategy {
+		case "", "drop_oldest":
+			strategy = middleware.DropOldest{}
+		case "keep_last":
+			if params.KeepLast <= 0 {
+				return nil, errors.New("keep_last must be positive")
+			}
+			strategy = middleware.KeepLastN{N: params.KeepLast}
+		default:
+			return nil, fmt.Errorf("unknown strategy %q", params.Strategy)
+		}
+		return middleware.NewContextManager(p, strategy), nil
+	}
+
+	return nil, fmt.Errorf("unknown middleware %q", s.Name)
+}
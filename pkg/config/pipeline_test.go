@@ -0,0 +1,319 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/logger"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/middleware"
+	"gopkg.in/yaml.v3"
+)
+
+const pipelineYAML = `
+active_provider: openai
+pipeline:
+  - tracing
+  - circuit_breaker: {threshold: 3, reset_timeout: 30s}
+  - retry: {max_retries: 2, base_delay: 1s}
+  - cost:
+      pricing:
+        my-model: {input: 1, output: 2}
+providers:
+  openai:
+    api_key: sk-test
+  ollama:
+    pipeline:
+      - rate_limit: {rps: 5}
+  bare:
+    type: ollama
+    pipeline: []
+`
+
+// chain lists the concrete types from the outermost layer inward.
+func chain(p ai.AIProvider) []string {
+	var names []string
+	for p != nil {
+		switch p.(type) {
+		case *middleware.TracingMiddleware:
+			names = append(names, "tracing")
+		case *middleware.CircuitBreaker:
+			names = append(names, "circuit_breaker")
+		case *middleware.ResilientClient:
+			names = append(names, "retry")
+		case *middleware.CostEstimator:
+			names = append(names, "cost")
+		case *middleware.RateLimiterMiddleware:
+			names = append(names, "rate_limit")
+		default:
+			names = append(names, "client")
+		}
+		u, ok := p.(ai.Unwrapper)
+		if !ok {
+			break
+		}
+		p = u.Unwrap()
+	}
+	return names
+}
+
+func TestBuildProviderFromPipeline(t *testing.T) {
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(pipelineYAML), &cfg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"", "tracing circuit_breaker retry cost client"},
+		{"ollama", "rate_limit client"},
+		{"bare", "client"},
+	}
+	for _, tt := range tests {
+		p, err := BuildProvider(&cfg, tt.name, &logger.NoOpLogger{})
+		if err != nil {
+			t.Fatalf("%q: %v", tt.name, err)
+		}
+		if got := strings.Join(chain(p), " "); got != tt.want {
+			t.Errorf("%q: chain = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryZeroDisablesRetries(t *testing.T) {
+	for src, want := range map[string]string{
+		"- retry: {max_retries: 0}": "client",
+		"- retry: {}":               "retry client",
+	} {
+		var stages []Stage
+		if err := yaml.Unmarshal([]byte(src), &stages); err != nil {
+			t.Fatal(err)
+		}
+		p, err := BuildPipeline(&ai.FallbackClient{}, stages, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(chain(p), " "); got != want {
+			t.Errorf("%s: chain = %s, want %s", src, got, want)
+		}
+	}
+}
+
+func TestBuildPipelineRejectsBadStages(t *testing.T) {
+	tests := map[string]string{
+		"- nonsense":                 `unknown middleware "nonsense"`,
+		"- rate_limit: {rps: 0}":     "rps must be positive",
+		"- context: {strategy: foo}": `unknown strategy "foo"`,
+		"- {retry: {}, cost: {}}":    "exactly one name",
+	}
+	for src, want := range tests {
+		var stages []Stage
+		err := yaml.Unmarshal([]byte(src), &stages)
+		if err == nil {
+			_, err = BuildPipeline(&ai.FallbackClient{}, stages, nil)
+		}
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", src, want, err)
+		}
+	}
+}
+
+const autoYAML = `
+active_provider: auto
+auto:
+  preference: [anthropic, ollama]
+  health_interval: 1h
+pipeline: []
+providers:
+  openai:
+    api_key: sk-test
+    base_url: http://127.0.0.1:1/v1/chat/completions
+  anthropic:
+    api_key: ""
+  google:
+    api_key: AIza-test
+    base_url: "http://127.0.0.1:1/models/%s:generateContent?key=%s"
+  ollama:
+    base_url: http://127.0.0.1:1/api/chat
+`
+
+func TestAutoProvider(t *testing.T) {
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(autoYAML), &cfg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("auto should validate: %v", err)
+	}
+
+	// anthropic has no key; unlisted providers follow alphabetically.
+	if got := strings.Join(cfg.AutoCandidates(), ","); got != "ollama,google,openai" {
+		t.Errorf("candidates = %s", got)
+	}
+
+	p, err := BuildProvider(&cfg, "", &logger.NoOpLogger{})
+	if err != nil {
+		t.Fatalf("BuildProvider: %v", err)
+	}
+	router, ok := p.(*ai.Router)
+	if !ok {
+		t.Fatalf("auto should build an *ai.Router, got %T", p)
+	}
+	defer router.Close()
+
+	if len(router.Providers()) != 3 {
+		t.Errorf("expected 3 members, got %d", len(router.Providers()))
+	}
+	if _, ok := ai.As[ai.HealthReporter](router.Providers()[0]); !ok {
+		t.Error("members should report health")
+	}
+
+	cfg.Auto.Preference = []string{"missing"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("unknown preference entry should fail validation")
+	}
+}
+
+func TestBuildProviderWithAliases(t *testing.T) {
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(`
+active_provider: openai
+aliases:
+  fast: {model: gpt-4o-mini}
+  smart: {provider: anthropic, model: claude-3-5-sonnet-20240620}
+providers:
+  openai:
+    api_key: sk-test
+    base_url: http://127.0.0.1:1/v1/chat/completions
+  anthropic:
+    api_key: sk-ant
+    base_url: http://127.0.0.1:1/v1/messages
+`), &cfg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("aliases should validate: %v", err)
+	}
+
+	p, err := BuildProvider(&cfg, "", &logger.NoOpLogger{})
+	if err != nil {
+		t.Fatalf("BuildProvider: %v", err)
+	}
+	router, ok := p.(*ai.AliasRouter)
+	if !ok {
+		t.Fatalf("aliases should build an *ai.AliasRouter, got %T", p)
+	}
+	if got := router.ResolveModel("smart"); got != "claude-3-5-sonnet-20240620" {
+		t.Errorf("smart resolved to %q", got)
+	}
+
+	// A named provider is built on its own, without alias routing.
+	if p, _ := BuildProvider(&cfg, "openai", &logger.NoOpLogger{}); p != nil {
+		if _, ok := p.(*ai.AliasRouter); ok {
+			t.Error("named providers should not be wrapped")
+		}
+	}
+
+	cfg.Aliases["vision"] = ai.Alias{Provider: "mistral", Model: "pixtral"}
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "aliases.vision.provider") {
+		t.Errorf("alias to an unknown provider should fail validation, got %v", err)
+	}
+}
+
+func TestBuildProviderInCodeWithAliases(t *testing.T) {
+	cfg := &Config{
+		Aliases: ai.Aliases{"fast": {Model: "gpt-4o-mini"}},
+		Providers: map[string]Provider{
+			"openai": {APIKey: "sk-test"},
+		},
+	}
+	if _, err := BuildProvider(cfg, "", &logger.NoOpLogger{}); err == nil || !strings.Contains(err.Error(), "active_provider") {
+		t.Errorf("expected a missing active_provider error, got %v", err)
+	}
+
+	cfg.ActiveProvider = "openai"
+	p, err := BuildProvider(cfg, "", &logger.NoOpLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.(*ai.AliasRouter); !ok {
+		t.Errorf("expected an *ai.AliasRouter, got %T", p)
+	}
+}
+
+func TestOverrideStage(t *testing.T) {
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(`
+pipeline:
+  - tracing
+  - retry: {max_retries: 2, base_delay: 1s}
+  - cost
+providers:
+  ollama:
+    pipeline: []
+`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	shared := cfg.Pipeline
+
+	if err := cfg.OverrideStage(StageRetry, map[string]interface{}{"max_retries": 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.OverrideStage(StageCircuitBreaker, map[string]interface{}{"threshold": 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, s := range cfg.Pipeline {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "tracing,circuit_breaker,retry,cost" {
+		t.Errorf("pipeline = %s", got)
+	}
+
+	var retry RetryParams
+	if err := cfg.Pipeline[2].Decode(&retry); err != nil {
+		t.Fatal(err)
+	}
+	if *retry.MaxRetries != 5 || retry.BaseDelay != time.Second {
+		t.Errorf("override should merge over the file: %+v", retry)
+	}
+
+	var before RetryParams
+	shared[1].Decode(&before)
+	if *before.MaxRetries != 2 {
+		t.Error("override must not modify the original stages")
+	}
+
+	if got := cfg.Providers["ollama"].Pipeline; len(got) != 2 || got[0].Name != StageCircuitBreaker {
+		t.Errorf("provider pipelines get overrides too: %+v", got)
+	}
+}
+
+func TestRemoveStage(t *testing.T) {
+	var cfg Config
+	if err := yaml.Unmarshal([]byte(pipelineYAML), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	shared := cfg.Pipeline
+	cfg.RemoveStage(StageRetry)
+	cfg.RemoveStage(StageRateLimit)
+
+	p, err := BuildProvider(&cfg, "", &logger.NoOpLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(chain(p), " "); got != "tracing circuit_breaker cost client" {
+		t.Errorf("chain = %s", got)
+	}
+	if got := cfg.Providers["ollama"].Pipeline; got == nil || len(got) != 0 {
+		t.Errorf("provider pipeline should be empty but kept, got %+v", got)
+	}
+	if len(shared) != 4 {
+		t.Error("RemoveStage must not modify the original stages")
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// Secret holds a credential. It prints, marshals and logs as "[REDACTED]";
+// call Value to get the real string.
+type Secret string
+
+func (s Secret) Value() string { return string(s) }
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string { return fmt.Sprintf("config.Secret(%q)", s.String()) }
+
+func (s Secret) MarshalYAML() (interface{}, error) { return s.String(), nil }
+
+func (s Secret) MarshalJSON() ([]byte, error) { return json.Marshal(s.String()) }
+
+func (s Secret) LogValue() slog.Value { return slog.StringValue(s.String()) }
+
+// SecretResolver fetches the value behind a reference such as
+// "file:/run/secrets/openai". Implementations are registered per scheme.
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// FileResolver reads mounted secrets. Relative paths are resolved against
+// Dir, or the working directory if Dir is empty. Surrounding whitespace,
+// including the trailing newline most secret mounts add, is trimmed.
+type FileResolver struct {
+	Dir string
+}
+
+func (f FileResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	path := ref
+	if !filepath.IsAbs(path) && f.Dir != "" {
+		path = filepath.Join(f.Dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]SecretResolver{"file": FileResolver{}}
+)
+
+// RegisterSecretResolver makes values of the form "<scheme>:<ref>" resolve
+// through r, e.g. a "vault" scheme backed by a secrets manager.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	resolvers[scheme] = r
+}
+
+// resolveRef resolves s if it starts with a registered scheme and returns
+// it unchanged otherwise.
+func resolveRef(ctx context.Context, s string) (string, bool, error) {
+	scheme, ref, ok := strings.Cut(s, ":")
+	if !ok {
+		return s, false, nil
+	}
+
+	resolversMu.RLock()
+	r, ok := resolvers[scheme]
+	resolversMu.RUnlock()
+	if !ok {
+		return s, false, nil
+	}
+
+	v, err := r.Resolve(ctx, ref)
+	if err != nil {
+		return "", false, fmt.Errorf("resolving %s secret: %w", scheme, err)
+	}
+	return v, true, nil
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigInterpolatesAndRedacts(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := writeFile(t, dir, "anthropic_key", "sk-ant-from-file\n")
+
+	t.Setenv("OPENAI_KEY", "sk-from-env")
+	t.Setenv("APP_TIMEOUT", "")
+	path := writeFile(t, dir, "config.yaml", `
+app:
+  timeout: ${APP_TIMEOUT:-45}
+active_provider: openai
+providers:
+  openai:
+    api_key: ${OPENAI_KEY}
+    model: ${OPENAI_MODEL:-gpt-4o}
+    base_url: "https://proxy/$${literal}"
+  anthropic:
+    api_key: file:`+secretPath+`
+`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	if cfg.App.Timeout != 45 {
+		t.Errorf("default not applied to int field: %d", cfg.App.Timeout)
+	}
+	openai := cfg.Providers["openai"]
+	if openai.APIKey.Value() != "sk-from-env" || openai.Model != "gpt-4o" {
+		t.Errorf("env not expanded: %+v", openai)
+	}
+	if openai.BaseURL != "https://proxy/${literal}" {
+		t.Errorf("escape not honored: %s", openai.BaseURL)
+	}
+	if got := cfg.Providers["anthropic"].APIKey.Value(); got != "sk-ant-from-file" {
+		t.Errorf("file secret not resolved: %q", got)
+	}
+
+	out, _ := yaml.Marshal(cfg)
+	js, _ := json.Marshal(cfg)
+	for _, dump := range []string{fmt.Sprintf("%+v", cfg), fmt.Sprintf("%#v", cfg), string(out), string(js)} {
+		if strings.Contains(dump, "sk-from-env") || strings.Contains(dump, "sk-ant-from-file") {
+			t.Errorf("secret leaked:\n%s", dump)
+		}
+	}
+}
+
+func TestLoadConfigMissingEnv(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", "active_provider: openai\nproviders:\n  openai:\n    api_key: ${GOPOLY_TEST_UNSET_KEY}\n")
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "line 4") || !strings.Contains(err.Error(), "GOPOLY_TEST_UNSET_KEY") {
+		t.Errorf("expected missing variable error with line, got %v", err)
+	}
+}
+
+func TestSecretRefsOnlyInSecretFields(t *testing.T) {
+	secretPath := writeFile(t, t.TempDir(), "token", "s3cret")
+	path := writeFile(t, t.TempDir(), "config.yaml", `
+active_provider: openai
+providers:
+  openai:
+    api_key: sk-test
+    model: file:`+secretPath+`
+    headers:
+      X-Token: file:`+secretPath+`
+  azure: &azure
+    type: openai
+    api_key: file:`+secretPath+`
+  azure_eu:
+    <<: *azure
+    base_url: https://eu.example.com/v1/chat/completions
+`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	openai := cfg.Providers["openai"]
+	if openai.Model != "file:"+secretPath {
+		t.Errorf("reference resolved outside a secret field: %q", openai.Model)
+	}
+	if openai.Headers["X-Token"].Value() != "s3cret" {
+		t.Errorf("header secret not resolved: %q", openai.Headers["X-Token"].Value())
+	}
+	if cfg.Providers["azure_eu"].APIKey.Value() != "s3cret" {
+		t.Errorf("secret reached through a merge key not resolved: %q", cfg.Providers["azure_eu"].APIKey.Value())
+	}
+	if out, _ := yaml.Marshal(cfg); strings.Contains(string(out), "s3cret") {
+		t.Errorf("secret leaked:\n%s", out)
+	}
+}
+
+type staticResolver map[string]string
+
+func (s staticResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	if v, ok := s[ref]; ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("no secret %q", ref)
+}
+
+func TestCustomSecretResolver(t *testing.T) {
+	RegisterSecretResolver("vault", staticResolver{"kv/openai": "sk-vault"})
+
+	path := writeFile(t, t.TempDir(), "config.yaml", "active_provider: openai\nproviders:\n  openai:\n    api_key: vault:kv/openai\n")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Providers["openai"].APIKey.Value() != "sk-vault" {
+		t.Errorf("vault secret not resolved")
+	}
+}
@@ -0,0 +1,538 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/logger"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/middleware"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/transport"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is one finding of Check. File, Line and Column locate it; values
+// set through the environment carry the variable's name as File instead.
+type Problem struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string // e.g. providers.openai.pipeline[2]
+	Message string
+
+	// Warning marks findings that do not prevent loading.
+	Warning bool
+}
+
+func (p Problem) String() string {
+	var b strings.Builder
+	switch {
+	case p.File != "" && p.Line > 0:
+		fmt.Fprintf(&b, "%s:%d:%d: ", p.File, p.Line, p.Column)
+	case p.File != "":
+		b.WriteString(p.File + ": ")
+	}
+	if p.Warning {
+		b.WriteString("warning: ")
+	}
+	if p.Path != "" {
+		b.WriteString(p.Path + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// ValidationError lists every error-level problem of a configuration.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = p.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// errorsOf returns a *ValidationError for the non-warning problems, or nil.
+func errorsOf(problems []Problem) error {
+	var errs []Problem
+	for _, p := range problems {
+		if !p.Warning {
+			errs = append(errs, p)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: errs}
+}
+
+// Check loads the layers of path like Load, but reports every problem
+// instead of failing: unknown keys, values of the wrong type, malformed
+// base URLs, negative timeouts, invalid pipeline parameters and, as
+// warnings, models the cost stage has no price for. err is only set when a
+// file cannot be read or parsed.
+func Check(path, profile string) (*Config, []Problem, error) {
+	d, err := layered(path, profile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var problems []Problem
+	knownFields(d.root, reflect.TypeOf(Config{}), nil, func(key *yaml.Node, at []string, msg string) {
+		p := d.problem(key, at, msg)
+		// GOPOLY_* is a shared namespace; unrelated variables only warn.
+		p.Warning = strings.HasPrefix(p.File, EnvPrefix)
+		problems = append(problems, p)
+	})
+
+	var cfg Config
+	if err := d.root.Decode(&cfg); err != nil {
+		var te *yaml.TypeError
+		var ne *nodeError
+		switch {
+		case errors.As(err, &te):
+			for _, msg := range te.Errors {
+				problems = append(problems, Problem{File: path, Message: msg})
+			}
+		case errors.As(err, &ne):
+			return &cfg, append(problems, d.problem(ne.node, nil, ne.msg)), nil
+		default:
+			return nil, nil, err
+		}
+	}
+
+	return &cfg, append(problems, cfg.check(d)...), nil
+}
+
+// Validate checks a configuration built in code. Load and Check run the
+// same checks with file positions.
+func (c *Config) Validate() error {
+	return errorsOf(c.check(nil))
+}
+
+func (c *Config) check(d *document) []Problem {
+	var problems []Problem
+	report := func(warning bool, n *yaml.Node, path []string, format string, args ...interface{}) {
+		if n == nil {
+			n = d.find(path)
+		}
+		p := d.problem(n, path, fmt.Sprintf(format, args...))
+		p.Warning = warning
+		problems = append(problems, p)
+	}
+	fail := func(path []string, format string, args ...interface{}) {
+		report(false, nil, path, format, args...)
+	}
+
+	switch c.ActiveProvider {
+	case "":
+		fail(nil, "active_provider is required")
+	case AutoProvider:
+		for i, name := range c.Auto.Preference {
+			if _, ok := c.Providers[name]; !ok {
+				fail([]string{"auto", "preference", strconv.Itoa(i)}, "provider '%s' not found in providers list", name)
+			}
+		}
+		if len(c.AutoCandidates()) == 0 {
+			fail([]string{"active_provider"}, "'auto' needs at least one provider with credentials")
+		}
+	default:
+		if p, ok := c.Providers[c.ActiveProvider]; !ok {
+			fail([]string{"active_provider"}, "provider '%s' not found in providers list", c.ActiveProvider)
+		} else if !p.hasCredentials(c.ActiveProvider) {
+			fail([]string{"providers", c.ActiveProvider}, "api_key is required for the active provider")
+		}
+	}
+	if c.Auto.HealthInterval < 0 {
+		fail([]string{"auto", "health_interval"}, "must not be negative")
+	}
+	if c.Auto.HealthTimeout < 0 {
+		fail([]string{"auto", "health_timeout"}, "must not be negative")
+	}
+
+	if c.App.Timeout < 0 {
+		fail([]string{"app", "timeout"}, "must not be negative")
+	}
+	checkTransport(c.Transport, []string{"transport"}, fail)
+
+	checkStages := func(stages []Stage, path []string) {
+		for i, s := range stages {
+			if _, err := s.wrap(nil, &logger.NoOpLogger{}); err != nil {
+				var ne *nodeError
+				if errors.As(err, &ne) {
+					report(false, ne.node, append(path, strconv.Itoa(i)), "%s: %s", s.Name, ne.msg)
+					continue
+				}
+				fail(append(path, strconv.Itoa(i)), "%s: %v", s.Name, err)
+			}
+		}
+	}
+	checkStages(c.Pipeline, []string{"pipeline"})
+
+	registered := make(map[string]bool)
+	for _, name := range ai.Providers() {
+		registered[name] = true
+	}
+
+	names := make([]string, 0, len(c.Providers))
+	for name := range c.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := c.Providers[name]
+		at := func(key ...string) []string {
+			return append([]string{"providers", name}, key...)
+		}
+
+		typ := p.Type
+		if typ == "" {
+			typ = name
+		}
+		if !registered[typ] {
+			fail(at("type"), "unknown provider type %q (registered: %s)", typ, strings.Join(ai.Providers(), ", "))
+		}
+
+		if p.BaseURL != "" {
+			if err := checkBaseURL(typ, p.BaseURL); err != nil {
+				fail(at("base_url"), "%v", err)
+			}
+		}
+		if p.Timeout < 0 {
+			fail(at("timeout"), "must not be negative")
+		}
+		if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 2) {
+			fail(at("temperature"), "must be between 0 and 2")
+		}
+		if p.MaxTokens < 0 {
+			fail(at("max_tokens"), "must not be negative")
+		}
+		if p.Transport != nil {
+			checkTransport(*p.Transport, at("transport"), fail)
+		}
+
+		if p.Pipeline != nil {
+			checkStages(p.Pipeline, at("pipeline"))
+		}
+		if p.Model != "" && !c.priced(name, p.Model) {
+			report(true, nil, at("model"), "no price for model %q, its cost will be reported as 0", p.Model)
+		}
+	}
+
+	aliases := make([]string, 0, len(c.Aliases))
+	for alias := range c.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	for _, alias := range aliases {
+		a := c.Aliases[alias]
+		at := []string{"aliases", alias}
+		if a.Provider == "" && a.Model == "" {
+			fail(at, "an alias needs a provider, a model or both")
+			continue
+		}
+
+		name := a.Provider
+		if name == "" {
+			name = c.ActiveProvider
+		}
+		if _, ok := c.Providers[name]; !ok {
+			if a.Provider != "" && a.Provider != AutoProvider {
+				fail(append(at, "provider"), "provider '%s' not found in providers list", a.Provider)
+			}
+			continue
+		}
+		if a.Model != "" && !c.priced(name, a.Model) {
+			report(true, nil, append(at, "model"), "no price for model %q, its cost will be reported as 0", a.Model)
+		}
+	}
+
+	return problems
+}
+
+// priced reports whether the cost stage of the named provider's pipeline
+// knows model. Providers without a cost stage need no prices.
+func (c *Config) priced(name, model string) bool {
+	pricing, ok := c.Pricing(name)
+	if !ok {
+		return true
+	}
+	_, ok = middleware.LookupPrice(pricing, model)
+	return ok
+}
+
+// checkBaseURL rejects URLs a client could not call. Google's base URL is
+// a format string taking the model and the API key.
+func checkBaseURL(typ, raw string) error {
+	if typ == ai.ProviderGoogle {
+		if n := strings.Count(raw, "%s"); n != 2 {
+			return fmt.Errorf("google base_url needs two %%s placeholders (model and API key), found %d", n)
+		}
+		raw = fmt.Sprintf(raw, "model", "key")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid base_url: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("base_url %q must be an http or https URL", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("base_url %q has no host", raw)
+	}
+	return nil
+}
+
+func checkTransport(tc transport.Config, path []string, fail func(path []string, format string, args ...interface{})) {
+	at := func(key string) []string {
+		return append(append([]string(nil), path...), key)
+	}
+	if tc.ProxyURL != "" {
+		if u, err := url.Parse(tc.ProxyURL); err != nil || u.Host == "" {
+			fail(at("proxy_url"), "invalid proxy URL %q", tc.ProxyURL)
+		}
+	}
+	if tc.Timeout < 0 {
+		fail(at("timeout"), "must not be negative")
+	}
+	if tc.IdleConnTimeout < 0 {
+		fail(at("idle_conn_timeout"), "must not be negative")
+	}
+	for key, v := range map[string]int{
+		"max_idle_conns":          tc.MaxIdleConns,
+		"max_idle_conns_per_host": tc.MaxIdleConnsPerHost,
+		"max_conns_per_host":      tc.MaxConnsPerHost,
+	} {
+		if v < 0 {
+			fail(at(key), "must not be negative")
+		}
+	}
+}
+
+// nodeError is an error about a specific YAML node.
+type nodeError struct {
+	node *yaml.Node
+	msg  string
+}
+
+func (e *nodeError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.node.Line, e.node.Column, e.msg)
+}
+
+// document is the merged YAML of every layer, with the file (or
+// environment variable) each node came from.
+type document struct {
+	root   *yaml.Node
+	origin map[*yaml.Node]string
+}
+
+func (d *document) track(n *yaml.Node, origin string) {
+	d.origin[n] = origin
+	for _, child := range n.Content {
+		d.track(child, origin)
+	}
+}
+
+// find returns the node at path, or its deepest ancestor present.
+func (d *document) find(path []string) *yaml.Node {
+	if d == nil {
+		return nil
+	}
+	n := d.root
+	for _, key := range path {
+		var next *yaml.Node
+		switch n.Kind {
+		case yaml.MappingNode:
+			next = lookup(n, key)
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(key); err == nil && i < len(n.Content) {
+				next = n.Content[i]
+			}
+		}
+		if next == nil {
+			break
+		}
+		n = next
+	}
+	return n
+}
+
+func (d *document) problem(n *yaml.Node, path []string, msg string) Problem {
+	p := Problem{Path: formatPath(path), Message: msg}
+	if d == nil || n == nil {
+		return p
+	}
+	p.File = d.origin[n]
+	if !strings.HasPrefix(p.File, EnvPrefix) {
+		p.Line, p.Column = n.Line, n.Column
+	}
+	return p
+}
+
+func formatPath(path []string) string {
+	var b strings.Builder
+	for _, key := range path {
+		if _, err := strconv.Atoi(key); err == nil {
+			b.WriteString("[" + key + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(key)
+	}
+	return b.String()
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// knownFields calls report for every mapping key under n that has no
+// matching field in t, the type n decodes into. Types with their own
+// UnmarshalYAML check themselves.
+func knownFields(n *yaml.Node, t reflect.Type, path []string, report func(key *yaml.Node, path []string, msg string)) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+	sub := func(key string) []string {
+		return append(append([]string(nil), path...), key)
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Value == "<<" {
+				merged := []*yaml.Node{value}
+				if value.Kind == yaml.SequenceNode {
+					merged = value.Content
+				}
+				for _, m := range merged {
+					knownFields(m, t, path, report)
+				}
+				continue
+			}
+			ft, ok := fields[key.Value]
+			if !ok {
+				report(key, path, unknownKey(key.Value, fields))
+				continue
+			}
+			knownFields(value, ft, sub(key.Value), report)
+		}
+
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			knownFields(n.Content[i+1], t.Elem(), sub(n.Content[i].Value), report)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if n.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range n.Content {
+			knownFields(item, t.Elem(), sub(strconv.Itoa(i)), report)
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of struct t to their field types, following
+// yaml.v3's naming rules.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			for k, v := range yamlFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func unknownKey(key string, fields map[string]reflect.Type) string {
+	// Longer keys tolerate more typos before a suggestion stops making sense.
+	best, bestDist := "", max(2, len(key)/3)+1
+	for name := range fields {
+		d := editDistance(key, name)
+		if d < bestDist || (d == bestDist && best != "" && betterTie(key, name, best)) {
+			best, bestDist = name, d
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf("unknown key %q, did you mean %q?", key, best)
+	}
+	return fmt.Sprintf("unknown key %q", key)
+}
+
+// betterTie prefers the name sharing a longer prefix with key, then the
+// alphabetically first, so suggestions are deterministic.
+func betterTie(key, name, best string) bool {
+	if a, b := commonPrefix(key, name), commonPrefix(key, best); a != b {
+		return a > b
+	}
+	return name < best
+}
+
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
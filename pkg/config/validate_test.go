@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckReportsPositions(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "config.yaml", `
+active_provider: google
+pipeline:
+  - retry: {max_retry: 2}
+  - cost
+providers:
+  google:
+    api_key: key
+    modle: gemini-1.5-pro
+    base_url: "https://example.com/models/%s:generateContent"
+`)
+	writeFile(t, dir, "config.prod.yaml", `
+providers:
+  google:
+    model: gemini-9
+    timeout: -1s
+`)
+	t.Setenv("GOPOLY_PROVIDERS__GOOGLE__TEMPRATURE", "0.5")
+
+	_, problems, err := Check(base, "prod")
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	prod := filepath.Join(dir, "config.prod.yaml")
+	want := []Problem{
+		{File: base, Line: 9, Column: 5, Path: "providers.google", Message: `unknown key "modle", did you mean "model"?`},
+		{File: "GOPOLY_PROVIDERS__GOOGLE__TEMPRATURE", Path: "providers.google", Message: `unknown key "temprature", did you mean "temperature"?`, Warning: true},
+		{File: base, Line: 4, Column: 13, Path: "pipeline[0]", Message: `retry: unknown key "max_retry", did you mean "max_retries"?`},
+		{File: base, Line: 10, Column: 15, Path: "providers.google.base_url", Message: "google base_url needs two %s placeholders (model and API key), found 1"},
+		{File: prod, Line: 5, Column: 14, Path: "providers.google.timeout", Message: "must not be negative"},
+		{File: prod, Line: 4, Column: 12, Path: "providers.google.model", Message: `no price for model "gemini-9", its cost will be reported as 0`, Warning: true},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d:\n%v", len(problems), len(want), problems)
+	}
+	for i := range want {
+		if problems[i] != want[i] {
+			t.Errorf("problem %d:\n got  %s\n want %s", i, problems[i], want[i])
+		}
+	}
+
+	_, err = Load(base, "prod")
+	var ve *ValidationError
+	if !errors.As(err, &ve) || len(ve.Problems) != 4 {
+		t.Fatalf("Load should fail with the 4 errors, got %v", err)
+	}
+	if !strings.Contains(err.Error(), prod+":5:14: providers.google.timeout") {
+		t.Errorf("error should be positioned: %v", err)
+	}
+}
+
+func TestCheckTypeErrorsAndBaseURLs(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", `
+app:
+  timeout: soon
+active_provider: openai
+providers:
+  openai:
+    api_key: key
+    base_url: "api.openai.com/v1"
+    pipeline:
+      - tracing: {sample: 1}
+      - rate_limit: {rps: 0}
+  ollama:
+    type: llama
+`)
+	_, problems, err := Check(path, "")
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	joined := strings.Join(got, "\n")
+	for _, want := range []string{
+		"line 3: cannot unmarshal",
+		`providers.ollama.type: unknown provider type "llama"`,
+		`providers.openai.base_url: base_url "api.openai.com/v1" must be an http or https URL`,
+		`:10:19: providers.openai.pipeline[0]: tracing: unknown key "sample"`,
+		`providers.openai.pipeline[1]: rate_limit: rps must be positive`,
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("missing %q in:\n%s", want, joined)
+		}
+	}
+}
+
+func TestValidateWithoutFile(t *testing.T) {
+	cfg := Config{
+		ActiveProvider: "openai",
+		Providers:      map[string]Provider{"openai": {APIKey: "key", Timeout: -1}},
+	}
+	err := cfg.Validate()
+	if err == nil || err.Error() != "providers.openai.timeout: must not be negative" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+)
+
+// BuildFunc turns a validated config into a provider pipeline.
+type BuildFunc func(cfg *Config) (ai.AIProvider, error)
+
+// Watcher serves a provider built from a config file and rebuilds it when
+// the file (or its profile overlay) changes or the process receives SIGHUP.
+// A reload that fails to load, validate or build is reported and the
+// running pipeline keeps serving.
+type Watcher struct {
+	path    string
+	profile string
+	build   BuildFunc
+	handle  *ai.Swappable
+
+	// Interval is how often the files are checked for changes; zero or
+	// less means DefaultWatchInterval.
+	Interval time.Duration
+
+	// OnReload is called after every reload attempt; err is nil when the
+	// new pipeline was swapped in. By default results go to stderr.
+	OnReload func(cfg *Config, err error)
+
+	mu      sync.Mutex
+	cfg     *Config
+	modTime map[string]time.Time
+}
+
+// DefaultWatchInterval is how often a Watcher checks its files by default.
+const DefaultWatchInterval = 2 * time.Second
+
+// NewWatcher loads and builds the initial pipeline; unlike reloads, a
+// failure here is returned. A nil build uses BuildProvider for the active
+// provider.
+func NewWatcher(path, profile string, build BuildFunc) (*Watcher, error) {
+	if build == nil {
+		build = func(cfg *Config) (ai.AIProvider, error) { return BuildProvider(cfg, "", nil) }
+	}
+
+	w := &Watcher{path: path, profile: profile, build: build, Interval: DefaultWatchInterval}
+	cfg, p, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	w.cfg = cfg
+	w.handle = ai.NewSwappable(p)
+	return w, nil
+}
+
+// Provider returns the stable handle; it stays valid across reloads.
+func (w *Watcher) Provider() ai.AIProvider {
+	return w.handle
+}
+
+// Config returns the configuration of the running pipeline.
+func (w *Watcher) Config() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cfg
+}
+
+// Reload rebuilds the pipeline and swaps it in. Requests already running
+// finish on the old pipeline, which is closed once drained.
+func (w *Watcher) Reload() error {
+	cfg, p, err := w.load()
+	if err == nil {
+		w.mu.Lock()
+		w.cfg = cfg
+		w.mu.Unlock()
+		w.handle.Swap(p)
+	}
+	w.report(cfg, err)
+	return err
+}
+
+// Run watches for changes and SIGHUP until ctx is done.
+func (w *Watcher) Run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			w.Reload()
+		case <-ticker.C:
+			if w.changed() {
+				w.Reload()
+			}
+		}
+	}
+}
+
+func (w *Watcher) files() []string {
+	files := []string{w.path}
+	if w.profile != "" {
+		files = append(files, ProfilePath(w.path, w.profile))
+	}
+	return files
+}
+
+func (w *Watcher) load() (*Config, ai.AIProvider, error) {
+	// Record modification times first, so an edit racing with the load
+	// triggers another reload rather than being missed.
+	w.snapshot()
+
+	cfg, err := Load(w.path, w.profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := w.build(cfg)
+	if err != nil {
+		return cfg, nil, fmt.Errorf("building provider: %w", err)
+	}
+	return cfg, p, nil
+}
+
+func (w *Watcher) snapshot() {
+	times := make(map[string]time.Time)
+	for _, f := range w.files() {
+		if info, err := os.Stat(f); err == nil {
+			times[f] = info.ModTime()
+		}
+	}
+	w.mu.Lock()
+	w.modTime = times
+	w.mu.Unlock()
+}
+
+func (w *Watcher) changed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, f := range w.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			// A file being replaced may briefly vanish; wait for it.
+			continue
+		}
+		if !info.ModTime().Equal(w.modTime[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) report(cfg *Config, err error) {
+	if w.OnReload != nil {
+		w.OnReload(cfg, err)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[Config] reload of %s rejected, keeping current pipeline: %v\n", w.path, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[Config] reloaded %s (active provider: %s)\n", w.path, cfg.ActiveProvider)
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ahmettasdemir/gopolyai/pkg/ai"
+	"github.com/ahmettasdemir/gopolyai/pkg/ai/mock"
+)
+
+func TestWatcherReloadsAndRejects(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", "active_provider: ollama\nproviders:\n  ollama: {model: llama3}\n")
+
+	built := func(cfg *Config) (ai.AIProvider, error) {
+		return mock.NewClient(cfg.Providers["ollama"].Model, false), nil
+	}
+	w, err := NewWatcher(path, "", built)
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	w.Interval = 10 * time.Millisecond
+
+	results := make(chan error, 4)
+	w.OnReload = func(cfg *Config, err error) { results <- err }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	handle := w.Provider()
+	answer := func() string {
+		resp, err := handle.Generate(context.Background(), ai.ChatRequest{})
+		if err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+		return resp.Content
+	}
+	if got := answer(); got != "MOCK: llama3" {
+		t.Fatalf("initial pipeline answered %q", got)
+	}
+
+	touch := func(content string) {
+		os.WriteFile(path, []byte(content), 0o600)
+		future := time.Now().Add(time.Hour)
+		os.Chtimes(path, future, future)
+	}
+
+	touch("active_provider: ollama\nproviders:\n  ollama: {model: qwen2}\n")
+	if err := <-results; err != nil {
+		t.Fatalf("valid reload rejected: %v", err)
+	}
+	if got := answer(); got != "MOCK: qwen2" {
+		t.Errorf("handle not swapped, answered %q", got)
+	}
+
+	touch("active_provider: missing\n")
+	if err := <-results; err == nil {
+		t.Fatal("invalid config should be rejected")
+	}
+	if got := answer(); got != "MOCK: qwen2" {
+		t.Errorf("rejected reload disrupted traffic, answered %q", got)
+	}
+	if w.Config().Providers["ollama"].Model != "qwen2" {
+		t.Error("Config should still describe the running pipeline")
+	}
+}
+
+func TestWatcherDefaultsNonPositiveInterval(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", "active_provider: ollama\nproviders:\n  ollama: {}\n")
+	w, err := NewWatcher(path, "", func(cfg *Config) (ai.AIProvider, error) {
+		return mock.NewClient("", false), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Interval = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.Run(ctx); err != nil {
+		t.Errorf("Run: %v", err)
+	}
+}